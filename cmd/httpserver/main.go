@@ -1,94 +1,178 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
-
-	"github.com/Quak1/learn-http-go/internal/headers"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/accesslog"
+	"github.com/Quak1/learn-http-go/internal/compress"
+	"github.com/Quak1/learn-http-go/internal/fileserver"
+	"github.com/Quak1/learn-http-go/internal/metrics"
+	"github.com/Quak1/learn-http-go/internal/mtls"
+	"github.com/Quak1/learn-http-go/internal/problem"
+	"github.com/Quak1/learn-http-go/internal/proxyproto"
 	"github.com/Quak1/learn-http-go/internal/request"
 	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/Quak1/learn-http-go/internal/router"
 	"github.com/Quak1/learn-http-go/internal/server"
 )
 
-const port = 42069
+const (
+	defaultAddr     = ":42069"
+	restartTimeout  = 10 * time.Second
+	shutdownTimeout = 30 * time.Second
+)
 
 func main() {
-	server, err := server.Serve(port, handler)
+	var clientCAs *x509.CertPool
+	if caFile := os.Getenv("TLS_CLIENT_CA_FILE"); caFile != "" {
+		var err error
+		clientCAs, err = mtls.LoadCertPool(caFile)
+		if err != nil {
+			log.Fatalf("Error loading client CAs: %v", err)
+		}
+	}
+
+	registry := metrics.NewRegistry()
+	serverMetrics := server.NewMetrics(registry)
+	r := newRouter(registry, clientCAs)
+	accessLog := accesslog.New(os.Stdout, accesslog.FormatCombined)
+	defer accessLog.Close()
+
+	socket, ln, stopReload, err := listen(clientCAs)
 	if err != nil {
 		log.Fatalf("Error starting server: %v", err)
 	}
-	defer server.Close()
-	log.Println("Server started on port", port)
+	defer stopReload()
+	srv := server.ServeListener(ln, accessLog.Wrap(compress.New().Wrap(r.Handle)))
+	defer srv.Close()
+	srv.SetServerHeader("learn-http-go")
+	srv.SetMetrics(serverMetrics)
+	if err := server.NotifyReady(); err != nil {
+		log.Println("Error: couldn't notify the previous process:", err)
+	}
+	log.Println("Server started on", srv.Addr())
 
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	signal.Notify(sigChan, append([]os.Signal{syscall.SIGINT, syscall.SIGTERM}, restartSignals...)...)
+	for sig := range sigChan {
+		if !slices.Contains(restartSignals, sig) {
+			break
+		}
+		if _, err := server.Restart(restartTimeout, socket); err != nil {
+			log.Println("Error: restart failed:", err)
+			continue
+		}
+		log.Println("Handed listener over to the new process")
+		break
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Println("Error: shutdown:", err)
+	}
 	log.Println("Server gracefully stopped")
 }
 
-func handler(w *response.Writer, req *request.Request) {
-	target := req.RequestLine.RequestTarget
-	if target == "/yourproblem" {
-		yourProblemHandler(w, req)
-	} else if target == "/myproblem" {
-		myProblemHandler(w, req)
-	} else if strings.HasPrefix(target, "/httpbin") {
-		proxyHandler(w, req)
-	} else if target == "/video" {
-		videoHandler(w, req)
+// listen uses the socket inherited from systemd or a previous process when
+// there is one, otherwise it listens on ADDR, which accepts host:port and
+// unix:/path.sock. PROXY_PROTOCOL_TRUSTED_CIDRS lists the load balancers
+// whose PROXY protocol headers are honored. It terminates TLS when
+// TLS_CERT_FILE and TLS_KEY_FILE are set, reloading the certificate on
+// SIGHUP, and clientCAs, from TLS_CLIENT_CA_FILE, enables client
+// certificates issued by those CAs. The socket is returned along with the
+// listener to serve from so it can be handed over on restart, and
+// stopReload ends the SIGHUP certificate reloading.
+func listen(clientCAs *x509.CertPool) (socket net.Listener, ln net.Listener, stopReload func(), err error) {
+	listeners, err := server.ListenersFromEnv()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(listeners) > 0 {
+		socket = listeners[0]
 	} else {
-		successHandler(w, req)
+		addr := os.Getenv("ADDR")
+		if addr == "" {
+			addr = defaultAddr
+		}
+		socket, err = server.Listen(addr)
+		if err != nil {
+			return nil, nil, nil, err
+		}
 	}
-}
 
-func myProblemHandler(w *response.Writer, req *request.Request) {
-	body := `<html>
-  <head>
-    <title>500 Internal Server Error</title>
-  </head>
-  <body>
-    <h1>Internal Server Error</h1>
-    <p>Okay, you know what? This one is on me.</p>
-  </body>
-</html>
-`
+	ln = socket
+	if cidrs := os.Getenv("PROXY_PROTOCOL_TRUSTED_CIDRS"); cidrs != "" {
+		ln, err = proxyproto.NewListener(socket, strings.Split(cidrs, ",")...)
+		if err != nil {
+			socket.Close()
+			return nil, nil, nil, err
+		}
+	}
 
-	w.WriteStatusLine(500)
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	if certFile == "" || keyFile == "" {
+		return socket, ln, func() {}, nil
+	}
 
-	headers := response.GetDefaultHeaders(len(body))
-	headers.Replace("Content-Type", "text/html")
-	w.WriteHeaders(headers)
+	certs, err := server.NewCertReloader(server.CertFiles{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		socket.Close()
+		return nil, nil, nil, err
+	}
+	stopReload = certs.ReloadOnSIGHUP()
 
-	w.WriteBody([]byte(body))
+	config := certs.TLSConfig()
+	if clientCAs != nil {
+		config = mtls.ServerConfig(config, clientCAs)
+	}
+	return socket, server.NewTLSListener(ln, config), stopReload, nil
 }
 
-func yourProblemHandler(w *response.Writer, req *request.Request) {
-	body := `<html>
-  <head>
-    <title>400 Bad Request</title>
-  </head>
-  <body>
-    <h1>Bad Request</h1>
-    <p>Your request honestly kinda sucked.</p>
-  </body>
-</html>
-`
+// newRouter builds the routes, /metrics requires a client certificate when
+// clientCAs is set.
+func newRouter(registry *metrics.Registry, clientCAs *x509.CertPool) *router.Router {
+	assets := fileserver.NewDir("./assets")
+	assets.Prefix = "/assets"
+
+	metricsHandler := registry.Handle
+	if clientCAs != nil {
+		require := &mtls.Require{CAs: clientCAs}
+		metricsHandler = require.Wrap(metricsHandler)
+	}
 
-	w.WriteStatusLine(400)
+	r := router.New()
+	r.Add("", "/yourproblem", problem.Handle(yourProblemHandler))
+	r.Add("", "/myproblem", problem.Handle(myProblemHandler))
+	r.Add("", "/httpbin", problem.Handle(proxyHandler))
+	r.Add("", "/httpbin/", problem.Handle(proxyHandler))
+	r.Add("", "/video", problem.Handle(videoHandler))
+	r.Get("/metrics", metricsHandler)
+	r.Add("", "/assets/", assets.Handle)
+	r.Add("", "/", successHandler)
+	return r
+}
 
-	headers := response.GetDefaultHeaders(len(body))
-	headers.Replace("Content-Type", "text/html")
-	w.WriteHeaders(headers)
+func myProblemHandler(w *response.Writer, req *request.Request) *problem.Problem {
+	return problem.New(response.StatusInternalServerError, "Okay, you know what? This one is on me.")
+}
 
-	w.WriteBody([]byte(body))
+func yourProblemHandler(w *response.Writer, req *request.Request) *problem.Problem {
+	return problem.New(response.StatusBadRequest, "Your request honestly kinda sucked.")
 }
 
 func successHandler(w *response.Writer, req *request.Request) {
@@ -103,22 +187,27 @@ func successHandler(w *response.Writer, req *request.Request) {
 </html>
 `
 
+	etag := response.StrongETag([]byte(body))
+	if !response.CheckPreconditions(w, req, etag, time.Time{}) {
+		return
+	}
+
 	w.WriteStatusLine(200)
 
 	headers := response.GetDefaultHeaders(len(body))
 	headers.Replace("Content-Type", "text/html")
+	headers.Set("ETag", etag)
 	w.WriteHeaders(headers)
 
 	w.WriteBody([]byte(body))
 }
 
-func proxyHandler(w *response.Writer, req *request.Request) {
+func proxyHandler(w *response.Writer, req *request.Request) *problem.Problem {
 	endpoint := strings.TrimPrefix(req.RequestLine.RequestTarget, "/httpbin/")
 	url := "https://httpbin.org/" + endpoint
 	resp, err := http.Get(url)
 	if err != nil {
-		yourProblemHandler(w, req)
-		return
+		return yourProblemHandler(w, req)
 	}
 	defer resp.Body.Close()
 
@@ -130,43 +219,36 @@ func proxyHandler(w *response.Writer, req *request.Request) {
 	h.Set("Trailer", "X-Content-SHA256, X-Content-Length")
 	w.WriteHeaders(h)
 
-	buff := make([]byte, 1024)
-	body := []byte{}
-	for {
-		n, err := resp.Body.Read(buff)
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			fmt.Println("Error: couldn't read response")
-			break
-		}
-		_, err = w.WriteChunkedBody(buff[:n])
-		body = append(body, buff[:n]...)
+	hash := sha256.New()
+	body := response.NewChunkedWriter(w)
+	n, err := io.Copy(io.MultiWriter(body, hash), resp.Body)
+	if err != nil {
+		fmt.Println("Error: couldn't read response")
 	}
 
-	w.WriteChunkedBodyDone()
-
-	sha256sum := fmt.Sprintf("%x", sha256.Sum256(body))
-	trailers := headers.NewHeaders()
-	trailers.Set("X-Content-SHA256", sha256sum)
-	trailers.Set("X-Content-Length", strconv.Itoa(len(body)))
-
-	w.WriteTrailers(trailers)
+	body.Trailers.Set("X-Content-SHA256", fmt.Sprintf("%x", hash.Sum(nil)))
+	body.Trailers.Set("X-Content-Length", strconv.FormatInt(n, 10))
+	body.Close()
+	return nil
 }
 
-func videoHandler(w *response.Writer, req *request.Request) {
-	video, err := os.ReadFile("./assets/vim.mp4")
+func videoHandler(w *response.Writer, req *request.Request) *problem.Problem {
+	video, err := os.Open("./assets/vim.mp4")
 	if err != nil {
-		fmt.Println("Couldn't read file:", err)
-		return
+		fmt.Println("Couldn't open file:", err)
+		return myProblemHandler(w, req)
 	}
+	defer video.Close()
 
-	w.WriteStatusLine(200)
-
-	headers := response.GetDefaultHeaders(len(video))
-	headers.Replace("Content-Type", "video/mp4")
-	w.WriteHeaders(headers)
+	info, err := video.Stat()
+	if err != nil {
+		fmt.Println("Couldn't stat file:", err)
+		return myProblemHandler(w, req)
+	}
 
-	w.WriteBody([]byte(video))
+	err = response.ServeContent(w, req, "video/mp4", info.ModTime(), video)
+	if err != nil {
+		fmt.Println("Couldn't serve file:", err)
+	}
+	return nil
 }
@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// restartSignals make the server hand its listener to a new process.
+var restartSignals = []os.Signal{syscall.SIGUSR2}
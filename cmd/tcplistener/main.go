@@ -37,8 +37,8 @@ func main() {
 		fmt.Println("- Version:", req.RequestLine.HttpVersion)
 
 		fmt.Println("Headers:")
-		for key, val := range req.Headers {
-			fmt.Printf("- %s: %s\n", key, val)
+		for key := range req.Headers {
+			fmt.Printf("- %s: %s\n", key, req.Headers.Get(key))
 		}
 
 		fmt.Println("Body:")
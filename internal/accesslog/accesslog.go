@@ -0,0 +1,199 @@
+package accesslog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/Quak1/learn-http-go/internal/server"
+)
+
+type Format int
+
+const (
+	FormatCommon Format = iota
+	FormatCombined
+	FormatJSON
+)
+
+const (
+	clfTime           = "02/Jan/2006:15:04:05 -0700"
+	defaultBufferSize = 1024
+)
+
+// Entry is what gets recorded for every request.
+type Entry struct {
+	RemoteAddr string
+	Time       time.Time
+	Method     string
+	Target     string
+	Version    string
+	Status     response.StatusCode
+	Bytes      int64
+	Duration   time.Duration
+	Referer    string
+	UserAgent  string
+}
+
+// Logger records every request handled by Wrap. Lines are queued and written
+// by a background goroutine, so a slow output never delays a response; when
+// the queue is full lines are dropped and counted instead.
+type Logger struct {
+	format Format
+	json   slog.Handler
+
+	out     io.Writer
+	lines   chan []byte
+	done    chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+	dropped atomic.Int64
+}
+
+func New(out io.Writer, format Format) *Logger {
+	l := &Logger{
+		format: format,
+		out:    out,
+		lines:  make(chan []byte, defaultBufferSize),
+		done:   make(chan struct{}),
+	}
+	l.json = slog.NewJSONHandler(queueWriter{l}, nil)
+
+	go l.run()
+
+	return l
+}
+
+func (l *Logger) Wrap(next server.Handler) server.Handler {
+	return func(w *response.Writer, req *request.Request) {
+		start := time.Now()
+		next(w, req)
+		w.Close()
+
+		l.Log(Entry{
+			RemoteAddr: req.RemoteAddr,
+			Time:       start,
+			Method:     req.RequestLine.Method,
+			Target:     req.RequestLine.RequestTarget,
+			Version:    req.RequestLine.HttpVersion,
+			Status:     w.Status(),
+			Bytes:      w.BytesWritten(),
+			Duration:   time.Since(start),
+			Referer:    req.Headers.Get("Referer"),
+			UserAgent:  req.Headers.Get("User-Agent"),
+		})
+	}
+}
+
+func (l *Logger) Log(e Entry) {
+	if l.format == FormatJSON {
+		// The record carries the request start time rather than the time the
+		// line is written.
+		r := slog.NewRecord(e.Time, slog.LevelInfo, "request", 0)
+		r.AddAttrs(
+			slog.String("remote_addr", e.RemoteAddr),
+			slog.String("method", e.Method),
+			slog.String("target", e.Target),
+			slog.String("version", e.Version),
+			slog.Int("status", int(e.Status)),
+			slog.Int64("bytes", e.Bytes),
+			slog.Duration("duration", e.Duration),
+			slog.String("referer", e.Referer),
+			slog.String("user_agent", e.UserAgent),
+		)
+		l.json.Handle(context.Background(), r)
+		return
+	}
+
+	line := formatCommon(e)
+	if l.format == FormatCombined {
+		line += fmt.Sprintf(" %s %s", quote(e.Referer), quote(e.UserAgent))
+	}
+	l.enqueue([]byte(line + "\n"))
+}
+
+// Dropped returns the number of lines lost because the queue was full.
+func (l *Logger) Dropped() int64 {
+	return l.dropped.Load()
+}
+
+// Close writes out the queued lines and stops the logger.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.lines)
+	}
+	l.mu.Unlock()
+
+	<-l.done
+	return nil
+}
+
+func (l *Logger) run() {
+	defer close(l.done)
+	for line := range l.lines {
+		l.out.Write(line)
+	}
+}
+
+func (l *Logger) enqueue(line []byte) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		return
+	}
+
+	select {
+	case l.lines <- line:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+type queueWriter struct {
+	l *Logger
+}
+
+func (q queueWriter) Write(p []byte) (int, error) {
+	q.l.enqueue(append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func formatCommon(e Entry) string {
+	host := e.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "" {
+		host = "-"
+	}
+
+	bytes := "-"
+	if e.Bytes > 0 {
+		bytes = strconv.FormatInt(e.Bytes, 10)
+	}
+
+	requestLine := fmt.Sprintf("%s %s HTTP/%s", e.Method, e.Target, e.Version)
+	return fmt.Sprintf("%s - - [%s] %s %d %s", host, e.Time.Format(clfTime), quote(requestLine), e.Status, bytes)
+}
+
+// quote wraps s in double quotes, escaping what would break the line apart.
+// Empty values are logged as "-".
+func quote(s string) string {
+	if s == "" {
+		return `"-"`
+	}
+	return `"` + logEscaper.Replace(s) + `"`
+}
+
+var logEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
@@ -0,0 +1,99 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hello(w *response.Writer, req *request.Request) {
+	w.WriteStatusLine(response.StatusOK)
+	w.WriteHeaders(response.GetDefaultHeaders(5))
+	w.WriteBody([]byte("hello"))
+}
+
+func logRequest(t *testing.T, format Format, raw string) string {
+	req, err := request.RequestFromReader(strings.NewReader(raw))
+	require.NoError(t, err)
+	req.RemoteAddr = "192.0.2.7:51234"
+
+	var out bytes.Buffer
+	l := New(&out, format)
+	l.Wrap(hello)(response.NewResponseWriter(&bytes.Buffer{}), req)
+	require.NoError(t, l.Close())
+	return out.String()
+}
+
+type blockingWriter struct {
+	mu sync.Mutex
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(p), nil
+}
+
+func TestLogger(t *testing.T) {
+	raw := "GET /coffee?x=1 HTTP/1.1\r\nReferer: http://example.com/\r\nUser-Agent: curl/8.0 \"test\"\r\n\r\n"
+
+	// Test: Common Log Format
+	line := logRequest(t, FormatCommon, raw)
+	assert.Regexp(t, regexp.MustCompile(`^192\.0\.2\.7 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /coffee\?x=1 HTTP/1\.1" 200 5\n$`), line)
+
+	// Test: Combined Log Format escapes quotes
+	line = logRequest(t, FormatCombined, raw)
+	assert.True(t, strings.HasSuffix(line, ` 200 5 "http://example.com/" "curl/8.0 \"test\""`+"\n"))
+
+	// Test: Missing fields
+	line = logRequest(t, FormatCombined, "HEAD / HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasSuffix(line, `"HEAD / HTTP/1.1" 200 5 "-" "-"`+"\n"))
+
+	// Test: JSON
+	line = logRequest(t, FormatJSON, raw)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal([]byte(line), &entry))
+	assert.Equal(t, "request", entry["msg"])
+	assert.Equal(t, "192.0.2.7:51234", entry["remote_addr"])
+	assert.Equal(t, "/coffee?x=1", entry["target"])
+	assert.Equal(t, float64(200), entry["status"])
+	assert.Equal(t, float64(5), entry["bytes"])
+	assert.Equal(t, "curl/8.0 \"test\"", entry["user_agent"])
+
+	// Test: JSON time is the request start time
+	var buff bytes.Buffer
+	l := New(&buff, FormatJSON)
+	l.Log(Entry{Time: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), Status: response.StatusOK})
+	require.NoError(t, l.Close())
+	require.NoError(t, json.Unmarshal(buff.Bytes(), &entry))
+	assert.Equal(t, "2025-01-02T03:04:05Z", entry["time"])
+
+	// Test: A blocked output drops lines instead of blocking
+	out := &blockingWriter{}
+	out.mu.Lock()
+	l = New(out, FormatCommon)
+	done := make(chan struct{})
+	go func() {
+		for range defaultBufferSize + 10 {
+			l.Log(Entry{Time: time.Now(), Status: response.StatusOK})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging blocked")
+	}
+	assert.Positive(t, l.Dropped())
+	out.mu.Unlock()
+	require.NoError(t, l.Close())
+}
@@ -0,0 +1,154 @@
+package compress
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/Quak1/learn-http-go/internal/negotiate"
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/Quak1/learn-http-go/internal/server"
+)
+
+const defaultMinSize = 1024
+
+var compressibleTypes = []string{
+	"text/",
+	"application/json",
+	"application/problem+json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+}
+
+// supported encodings in order of preference when q-values tie
+var supportedEncodings = []string{"gzip", "deflate"}
+
+type Compressor struct {
+	MinSize int
+	Level   int
+}
+
+func New() *Compressor {
+	return &Compressor{
+		MinSize: defaultMinSize,
+		Level:   gzip.DefaultCompression,
+	}
+}
+
+// Wrap compresses responses that declare a Content-Length of at least
+// MinSize and a compressible Content-Type, switching them to chunked framing.
+// Responses that are already chunked, encoded or partial are left untouched.
+//
+// The choice is made when the handler writes its headers, before any body,
+// so a response without a Content-Length is never compressed, even when the
+// writer later infers its length or its body turns out to be large.
+func (c *Compressor) Wrap(next server.Handler) server.Handler {
+	return func(w *response.Writer, req *request.Request) {
+		encoding := NegotiateEncoding(req.Headers.Get("Accept-Encoding"))
+
+		w.AddHeaderHook(func(status response.StatusCode, h headers.Headers, body io.Writer) io.WriteCloser {
+			if !c.shouldCompress(status, h) {
+				return nil
+			}
+
+			h.Set("Vary", "Accept-Encoding")
+			if encoding == "" {
+				return nil
+			}
+
+			encoder, err := newEncoder(encoding, c.Level, body)
+			if err != nil {
+				return nil
+			}
+
+			h.Delete("Content-Length")
+			h.Set("Content-Encoding", encoding)
+			h.Set("Transfer-Encoding", "chunked")
+			if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+				h.Replace("ETag", "W/"+etag)
+			}
+			return encoder
+		})
+
+		next(w, req)
+		w.Close()
+	}
+}
+
+func (c *Compressor) shouldCompress(status response.StatusCode, h headers.Headers) bool {
+	if status < 200 || status == 204 || status == response.StatusNotModified || status == response.StatusPartialContent {
+		return false
+	}
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" || h.Get("Transfer-Encoding") != "" {
+		return false
+	}
+
+	length, err := strconv.Atoi(h.Get("Content-Length"))
+	if err != nil || length < c.MinSize {
+		return false
+	}
+
+	return isCompressible(h.Get("Content-Type"))
+}
+
+func isCompressible(contentType string) bool {
+	parsed, err := headers.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	mediaType := parsed.Essence()
+	for _, t := range compressibleTypes {
+		if strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t) {
+			return true
+		}
+		if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateEncoding picks the supported content coding with the highest
+// q-value in an Accept-Encoding header, or "" to send the identity coding.
+func NegotiateEncoding(acceptEncoding string) string {
+	qualities := map[string]float64{}
+	wildcard := -1.0
+	for _, spec := range negotiate.Parse(acceptEncoding) {
+		if spec.Value == "*" {
+			wildcard = spec.Q
+		} else {
+			qualities[spec.Value] = spec.Q
+		}
+	}
+
+	best := ""
+	bestQ := 0.0
+	for _, encoding := range supportedEncodings {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = max(wildcard, 0)
+		}
+		if q > bestQ {
+			best = encoding
+			bestQ = q
+		}
+	}
+
+	return best
+}
+
+func newEncoder(encoding string, level int, body io.Writer) (io.WriteCloser, error) {
+	switch encoding {
+	case "gzip":
+		return gzip.NewWriterLevel(body, level)
+	case "deflate":
+		return zlib.NewWriterLevel(body, level)
+	}
+	return nil, fmt.Errorf("Error: unsupported encoding %q", encoding)
+}
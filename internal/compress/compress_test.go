@@ -0,0 +1,157 @@
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var largeBody = strings.Repeat("<p>Your request was an absolute banger.</p>\n", 100)
+
+func textHandler(body, contentType string) func(w *response.Writer, req *request.Request) {
+	return func(w *response.Writer, req *request.Request) {
+		w.WriteStatusLine(response.StatusOK)
+		h := response.GetDefaultHeaders(len(body))
+		h.Replace("Content-Type", contentType)
+		w.WriteHeaders(h)
+		w.WriteBody([]byte(body))
+	}
+}
+
+func run(t *testing.T, handler func(w *response.Writer, req *request.Request), raw string) *http.Response {
+	req, err := request.RequestFromReader(strings.NewReader(raw))
+	require.NoError(t, err)
+
+	var buff bytes.Buffer
+	New().Wrap(handler)(response.NewResponseWriter(&buff), req)
+
+	resp, err := http.ReadResponse(bufio.NewReader(&buff), nil)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	assert.Equal(t, "gzip", NegotiateEncoding("gzip, deflate"))
+	assert.Equal(t, "deflate", NegotiateEncoding("gzip;q=0.5, deflate;q=0.8"))
+	assert.Equal(t, "gzip", NegotiateEncoding("br, *;q=0.1"))
+	assert.Equal(t, "deflate", NegotiateEncoding("gzip;q=0, *"))
+	assert.Equal(t, "", NegotiateEncoding("br"))
+	assert.Equal(t, "", NegotiateEncoding(""))
+}
+
+func TestCompressor(t *testing.T) {
+	// Test: Large text body is gzipped and chunked
+	resp := run(t, textHandler(largeBody, "text/html"), "GET / HTTP/1.1\r\nAccept-Encoding: gzip, deflate\r\n\r\n")
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+	assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+	zr, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, largeBody, string(body))
+
+	// Test: Deflate when preferred
+	resp = run(t, textHandler(largeBody, "application/json"), "GET / HTTP/1.1\r\nAccept-Encoding: gzip;q=0.1, deflate\r\n\r\n")
+	assert.Equal(t, "deflate", resp.Header.Get("Content-Encoding"))
+	zlr, err := zlib.NewReader(resp.Body)
+	require.NoError(t, err)
+	body, err = io.ReadAll(zlr)
+	require.NoError(t, err)
+	assert.Equal(t, largeBody, string(body))
+
+	// Test: Client without Accept-Encoding still gets Vary
+	resp = run(t, textHandler(largeBody, "text/html"), "GET / HTTP/1.1\r\n\r\n")
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, largeBody, string(body))
+
+	// Test: Small body is not compressed
+	resp = run(t, textHandler("tiny", "text/plain"), "GET / HTTP/1.1\r\nAccept-Encoding: gzip\r\n\r\n")
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, int64(4), resp.ContentLength)
+
+	// Test: Incompressible content type is not compressed
+	resp = run(t, textHandler(largeBody, "video/mp4"), "GET / HTTP/1.1\r\nAccept-Encoding: gzip\r\n\r\n")
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+
+	// Test: Body without a declared length is not compressed
+	undeclared := func(w *response.Writer, req *request.Request) {
+		w.WriteStatusLine(response.StatusOK)
+		h := response.GetDefaultHeaders(0)
+		h.Delete("Content-Length")
+		w.WriteHeaders(h)
+		w.WriteBody([]byte(largeBody))
+	}
+	resp = run(t, undeclared, "GET / HTTP/1.1\r\nAccept-Encoding: gzip\r\n\r\n")
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, largeBody, string(body))
+
+	// Test: Range responses are not compressed
+	partial := func(w *response.Writer, req *request.Request) {
+		w.WriteStatusLine(response.StatusPartialContent)
+		h := response.GetDefaultHeaders(len(largeBody))
+		h.Set("Content-Range", "bytes 0-4499/9000")
+		w.WriteHeaders(h)
+		w.WriteBody([]byte(largeBody))
+	}
+	resp = run(t, partial, "GET / HTTP/1.1\r\nAccept-Encoding: gzip\r\nRange: bytes=0-4499\r\n\r\n")
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+}
+
+func TestDecompressor(t *testing.T) {
+	called := false
+	echo := func(w *response.Writer, req *request.Request) {
+		called = true
+		textHandler(string(req.Body), "text/plain")(w, req)
+	}
+	decode := func(raw string) *http.Response {
+		req, err := request.RequestFromReader(strings.NewReader(raw))
+		require.NoError(t, err)
+
+		var buff bytes.Buffer
+		NewDecompressor().Wrap(echo)(response.NewResponseWriter(&buff), req)
+
+		resp, err := http.ReadResponse(bufio.NewReader(&buff), nil)
+		require.NoError(t, err)
+		return resp
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("hello"))
+	zw.Close()
+
+	// Test: gzip body is decoded before the handler runs
+	resp := decode("POST / HTTP/1.1\r\nContent-Encoding: gzip\r\nContent-Length: " + strconv.Itoa(gz.Len()) + "\r\n\r\n" + gz.String())
+	assert.Equal(t, 200, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+
+	// Test: Unsupported coding is rejected with 415
+	called = false
+	resp = decode("POST / HTTP/1.1\r\nContent-Encoding: br\r\nContent-Length: 2\r\n\r\nhi")
+	assert.Equal(t, 415, resp.StatusCode)
+	assert.Equal(t, "gzip, deflate", resp.Header.Get("Accept-Encoding"))
+	assert.False(t, called)
+
+	// Test: Corrupt body is rejected with 400
+	resp = decode("POST / HTTP/1.1\r\nContent-Encoding: gzip\r\nContent-Length: 2\r\n\r\nhi")
+	assert.Equal(t, 400, resp.StatusCode)
+}
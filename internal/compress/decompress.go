@@ -0,0 +1,43 @@
+package compress
+
+import (
+	"errors"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/Quak1/learn-http-go/internal/server"
+)
+
+const defaultMaxDecodedSize = 10 << 20
+
+type Decompressor struct {
+	MaxSize int64
+}
+
+func NewDecompressor() *Decompressor {
+	return &Decompressor{
+		MaxSize: defaultMaxDecodedSize,
+	}
+}
+
+// Wrap decodes gzip and deflate request bodies before calling next, replying
+// 415 for other codings, 413 when the decoded body is too large and 400 when
+// it is corrupt.
+func (d *Decompressor) Wrap(next server.Handler) server.Handler {
+	return func(w *response.Writer, req *request.Request) {
+		err := req.DecodeBody(d.MaxSize)
+		switch {
+		case err == nil:
+			next(w, req)
+		case errors.Is(err, request.ErrUnsupportedEncoding):
+			h := headers.NewHeaders()
+			h.Set("Accept-Encoding", "gzip, deflate")
+			response.WriteError(w, response.StatusUnsupportedMedia, h)
+		case errors.Is(err, request.ErrBodyTooLarge):
+			response.WriteError(w, response.StatusContentTooLarge, nil)
+		default:
+			response.WriteError(w, response.StatusBadRequest, nil)
+		}
+	}
+}
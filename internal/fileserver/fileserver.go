@@ -0,0 +1,257 @@
+package fileserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+)
+
+const sniffLen = 512
+
+type FileServer struct {
+	// Prefix is stripped from the request path before looking up the file.
+	Prefix          string
+	ListDirectories bool
+
+	root fs.FS
+}
+
+func New(root fs.FS) *FileServer {
+	return &FileServer{root: root}
+}
+
+func NewDir(dir string) *FileServer {
+	return New(os.DirFS(dir))
+}
+
+func (f *FileServer) Handle(w *response.Writer, req *request.Request) {
+	method := req.RequestLine.Method
+	if method != "GET" && method != "HEAD" {
+		h := headers.NewHeaders()
+		h.Set("Allow", "GET, HEAD")
+		response.WriteError(w, response.StatusMethodNotAllowed, h)
+		return
+	}
+
+	target, _, _ := strings.Cut(req.RequestLine.RequestTarget, "?")
+	urlPath, err := url.PathUnescape(target)
+	if err != nil || strings.Contains(urlPath, "\x00") {
+		notFound(w)
+		return
+	}
+
+	fullPath := urlPath
+	urlPath, ok := strings.CutPrefix(urlPath, f.Prefix)
+	if !ok || (urlPath != "" && !strings.HasPrefix(urlPath, "/")) {
+		notFound(w)
+		return
+	}
+
+	name, ok := cleanPath(urlPath)
+	if !ok {
+		notFound(w)
+		return
+	}
+
+	file, err := f.root.Open(name)
+	if err != nil {
+		notFound(w)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		notFound(w)
+		return
+	}
+
+	if info.IsDir() {
+		if !strings.HasSuffix(target, "/") {
+			// A relative reference keeps the client on this host whatever
+			// the request target looked like, "//host" included.
+			redirect(w, (&url.URL{Path: path.Base(fullPath) + "/"}).String())
+			return
+		}
+
+		index, err := f.root.Open(path.Join(name, "index.html"))
+		if err == nil {
+			defer index.Close()
+			indexInfo, err := index.Stat()
+			if err == nil && !indexInfo.IsDir() {
+				f.serveFile(w, req, index, indexInfo)
+				return
+			}
+		}
+
+		if !f.ListDirectories {
+			notFound(w)
+			return
+		}
+		f.serveListing(w, name, urlPath)
+		return
+	}
+
+	f.serveFile(w, req, file, info)
+}
+
+func (f *FileServer) serveFile(w *response.Writer, req *request.Request, file fs.File, info fs.FileInfo) {
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		serveStream(w, req, file, info)
+		return
+	}
+
+	contentType, err := detectContentType(info.Name(), content)
+	if err != nil {
+		notFound(w)
+		return
+	}
+
+	err = response.ServeContent(w, req, contentType, info.ModTime(), content)
+	if err != nil {
+		log.Println("Error: couldn't serve file:", err)
+	}
+}
+
+// serveStream copies a file that can't seek straight to the client, so it is
+// sent whole without range support.
+func serveStream(w *response.Writer, req *request.Request, file fs.File, info fs.FileInfo) {
+	buff := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, buff)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		notFound(w)
+		return
+	}
+	contentType := mime.TypeByExtension(path.Ext(info.Name()))
+	if contentType == "" {
+		contentType = http.DetectContentType(buff[:n])
+	}
+
+	modtime := info.ModTime()
+	etag := ""
+	if !modtime.IsZero() {
+		etag = response.FileETag(info.Size(), modtime)
+	}
+	if !response.CheckPreconditions(w, req, etag, modtime) {
+		return
+	}
+
+	h := response.GetDefaultHeaders(int(info.Size()))
+	h.Replace("Content-Type", contentType)
+	if !modtime.IsZero() {
+		h.Set("ETag", etag)
+		h.SetTime("Last-Modified", modtime)
+	}
+	w.WriteStatusLine(response.StatusOK)
+	w.WriteHeaders(h)
+
+	_, err = io.Copy(bodyWriter{w}, io.MultiReader(bytes.NewReader(buff[:n]), file))
+	if err != nil {
+		log.Println("Error: couldn't serve file:", err)
+	}
+}
+
+type bodyWriter struct {
+	w *response.Writer
+}
+
+func (b bodyWriter) Write(p []byte) (int, error) {
+	return b.w.WriteBody(p)
+}
+
+func (f *FileServer) serveListing(w *response.Writer, name, urlPath string) {
+	entries, err := fs.ReadDir(f.root, name)
+	if err != nil {
+		notFound(w)
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	var b strings.Builder
+	title := html.EscapeString(urlPath)
+	fmt.Fprintf(&b, "<html>\n  <head>\n    <title>Index of %s</title>\n  </head>\n  <body>\n    <h1>Index of %s</h1>\n    <ul>\n", title, title)
+	if name != "." {
+		b.WriteString("      <li><a href=\"../\">../</a></li>\n")
+	}
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() {
+			entryName += "/"
+		}
+		href := (&url.URL{Path: entryName}).EscapedPath()
+		fmt.Fprintf(&b, "      <li><a href=\"%s\">%s</a></li>\n", html.EscapeString(href), html.EscapeString(entryName))
+	}
+	b.WriteString("    </ul>\n  </body>\n</html>\n")
+
+	body := b.String()
+	w.WriteStatusLine(response.StatusOK)
+	h := response.GetDefaultHeaders(len(body))
+	h.Replace("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeaders(h)
+	w.WriteBody([]byte(body))
+}
+
+// cleanPath turns a request path into an fs.FS name, rejecting anything that
+// would escape the root.
+func cleanPath(p string) (string, bool) {
+	if strings.Contains(p, "\\") {
+		return "", false
+	}
+	for _, elem := range strings.Split(p, "/") {
+		if elem == ".." {
+			return "", false
+		}
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		name = "."
+	}
+
+	return name, fs.ValidPath(name)
+}
+
+func detectContentType(name string, content io.ReadSeeker) (string, error) {
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		return contentType, nil
+	}
+
+	buff := make([]byte, sniffLen)
+	n, err := io.ReadFull(content, buff)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return http.DetectContentType(buff[:n]), nil
+}
+
+func redirect(w *response.Writer, location string) {
+	h := headers.NewHeaders()
+	h.Set("Location", location)
+	response.WriteError(w, response.StatusMovedPermanently, h)
+}
+
+func notFound(w *response.Writer) {
+	response.WriteError(w, response.StatusNotFound, nil)
+}
@@ -0,0 +1,136 @@
+package fileserver
+
+import (
+	"bytes"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFS = fstest.MapFS{
+	"style.css":         {Data: []byte("body {}"), ModTime: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+	"noext":             {Data: []byte("<html><body>sniffed</body></html>")},
+	"docs/index.html":   {Data: []byte("<h1>docs</h1>")},
+	"images/a<b>.png":   {Data: []byte("\x89PNG\r\n\x1a\n")},
+	"images/logo.png":   {Data: []byte("\x89PNG\r\n\x1a\n")},
+	"images/sub/x.txt":  {Data: []byte("x")},
+	"private/secret.go": {Data: []byte("package secret")},
+}
+
+func serve(t *testing.T, f *FileServer, raw string) string {
+	req, err := request.RequestFromReader(strings.NewReader(raw))
+	require.NoError(t, err)
+
+	var buff bytes.Buffer
+	f.Handle(response.NewResponseWriter(&buff), req)
+	return buff.String()
+}
+
+func TestFileServer(t *testing.T) {
+	f := New(testFS)
+	f.Prefix = "/assets"
+
+	// Test: File with known extension
+	out := serve(t, f, "GET /assets/style.css HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n"))
+	assert.Contains(t, out, "content-type: text/css; charset=utf-8\r\n")
+	assert.Contains(t, out, "last-modified: Thu, 02 Jan 2025 03:04:05 GMT\r\n")
+	assert.True(t, strings.HasSuffix(out, "body {}"))
+
+	// Test: Content type sniffed without extension
+	out = serve(t, f, "GET /assets/noext HTTP/1.1\r\n\r\n")
+	assert.Contains(t, out, "content-type: text/html; charset=utf-8\r\n")
+
+	// Test: Directory serves index.html
+	out = serve(t, f, "GET /assets/docs/ HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasSuffix(out, "<h1>docs</h1>"))
+
+	// Test: Directory without trailing slash redirects
+	out = serve(t, f, "GET /assets/docs HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 301 Moved Permanently\r\n"))
+	assert.Contains(t, out, "location: docs/\r\n")
+
+	// Test: Redirect stays relative for a target starting with two slashes
+	root := New(testFS)
+	out = serve(t, root, "GET //docs HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 301 Moved Permanently\r\n"))
+	assert.Contains(t, out, "location: docs/\r\n")
+
+	// Test: Prefix alone redirects to the root directory
+	out = serve(t, f, "GET /assets HTTP/1.1\r\n\r\n")
+	assert.Contains(t, out, "location: assets/\r\n")
+
+	// Test: Directory listing disabled
+	out = serve(t, f, "GET /assets/images/ HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 404 Not Found\r\n"))
+
+	// Test: Directory listing enabled escapes names
+	f.ListDirectories = true
+	out = serve(t, f, "GET /assets/images/ HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n"))
+	assert.Contains(t, out, `<a href="logo.png">logo.png</a>`)
+	assert.Contains(t, out, `<a href="sub/">sub/</a>`)
+	assert.Contains(t, out, `a&lt;b&gt;.png</a>`)
+
+	// Test: Missing file
+	out = serve(t, f, "GET /assets/missing.txt HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 404 Not Found\r\n"))
+
+	// Test: Path traversal
+	out = serve(t, f, "GET /assets/../private/secret.go HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 404 Not Found\r\n"))
+
+	// Test: Prefix must end at a path segment
+	out = serve(t, f, "GET /assetsstyle.css HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 404 Not Found\r\n"))
+
+	// Test: Encoded path traversal
+	out = serve(t, f, "GET /assets/%2e%2e/private/secret.go HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 404 Not Found\r\n"))
+
+	// Test: Unsupported method
+	out = serve(t, f, "POST /assets/style.css HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 405 Method Not Allowed\r\n"))
+	assert.Contains(t, out, "allow: GET, HEAD\r\n")
+}
+
+// streamFS hides Seek from its files like an archive or network filesystem.
+type streamFS struct {
+	fs.FS
+}
+
+func (s streamFS) Open(name string) (fs.File, error) {
+	file, err := s.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{file}, nil
+}
+
+func TestFileServerStream(t *testing.T) {
+	f := New(streamFS{testFS})
+
+	// Test: File without Seek is streamed whole
+	out := serve(t, f, "GET /style.css HTTP/1.1\r\nRange: bytes=0-1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n"))
+	assert.Contains(t, out, "content-type: text/css; charset=utf-8\r\n")
+	assert.Contains(t, out, "content-length: 7\r\n")
+	assert.NotContains(t, out, "accept-ranges")
+	assert.True(t, strings.HasSuffix(out, "\r\n\r\nbody {}"))
+
+	// Test: Content type sniffed from the streamed bytes
+	out = serve(t, f, "GET /noext HTTP/1.1\r\n\r\n")
+	assert.Contains(t, out, "content-type: text/html; charset=utf-8\r\n")
+	assert.True(t, strings.HasSuffix(out, "<html><body>sniffed</body></html>"))
+
+	// Test: Conditional request still answered
+	out = serve(t, f, "GET /style.css HTTP/1.1\r\nIf-Modified-Since: Thu, 02 Jan 2025 03:04:05 GMT\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 304 Not Modified\r\n"))
+}
@@ -0,0 +1,197 @@
+package headers
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CacheControl holds the Cache-Control directives of RFC 9111 and RFC 8246.
+// Delta-second directives are -1 when absent. Other directives are kept in
+// Extensions.
+type CacheControl struct {
+	MaxAge               int
+	SMaxAge              int
+	MaxStale             int
+	MinFresh             int
+	StaleWhileRevalidate int
+	StaleIfError         int
+
+	NoCache         bool
+	NoStore         bool
+	NoTransform     bool
+	OnlyIfCached    bool
+	MustRevalidate  bool
+	ProxyRevalidate bool
+	MustUnderstand  bool
+	Public          bool
+	Private         bool
+	Immutable       bool
+
+	// NoCacheFields and PrivateFields list the field names qualifying the
+	// no-cache and private response directives.
+	NoCacheFields []string
+	PrivateFields []string
+
+	Extensions map[string]string
+}
+
+// AnyStale is the MaxStale of a max-stale directive without a value.
+const AnyStale = math.MaxInt
+
+func NewCacheControl() CacheControl {
+	return CacheControl{
+		MaxAge:               -1,
+		SMaxAge:              -1,
+		MaxStale:             -1,
+		MinFresh:             -1,
+		StaleWhileRevalidate: -1,
+		StaleIfError:         -1,
+		Extensions:           map[string]string{},
+	}
+}
+
+func ParseCacheControl(s string) (CacheControl, error) {
+	cc := NewCacheControl()
+	for _, part := range splitList(s, ',') {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !isToken(name) {
+			return CacheControl{}, fmt.Errorf("Error: invalid Cache-Control directive %q", name)
+		}
+		value, err := unquote(strings.TrimSpace(value))
+		if err != nil {
+			return CacheControl{}, err
+		}
+
+		seconds := func() (int, error) {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("Error: invalid %s value %q", name, value)
+			}
+			return n, nil
+		}
+
+		switch name {
+		case "max-age":
+			cc.MaxAge, err = seconds()
+		case "s-maxage":
+			cc.SMaxAge, err = seconds()
+		case "max-stale":
+			cc.MaxStale = AnyStale
+			if hasValue {
+				cc.MaxStale, err = seconds()
+			}
+		case "min-fresh":
+			cc.MinFresh, err = seconds()
+		case "stale-while-revalidate":
+			cc.StaleWhileRevalidate, err = seconds()
+		case "stale-if-error":
+			cc.StaleIfError, err = seconds()
+		case "no-cache":
+			cc.NoCache = true
+			cc.NoCacheFields = fieldNames(value)
+		case "private":
+			cc.Private = true
+			cc.PrivateFields = fieldNames(value)
+		case "no-store":
+			cc.NoStore = true
+		case "no-transform":
+			cc.NoTransform = true
+		case "only-if-cached":
+			cc.OnlyIfCached = true
+		case "must-revalidate":
+			cc.MustRevalidate = true
+		case "proxy-revalidate":
+			cc.ProxyRevalidate = true
+		case "must-understand":
+			cc.MustUnderstand = true
+		case "public":
+			cc.Public = true
+		case "immutable":
+			cc.Immutable = true
+		default:
+			cc.Extensions[name] = value
+		}
+		if err != nil {
+			return CacheControl{}, err
+		}
+	}
+	return cc, nil
+}
+
+func fieldNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (cc CacheControl) String() string {
+	var directives []string
+	flag := func(set bool, name string, fields []string) {
+		if !set {
+			return
+		}
+		if len(fields) > 0 {
+			name += `="` + strings.Join(fields, ", ") + `"`
+		}
+		directives = append(directives, name)
+	}
+	seconds := func(n int, name string) {
+		if n >= 0 {
+			directives = append(directives, name+"="+strconv.Itoa(n))
+		}
+	}
+
+	flag(cc.Public, "public", nil)
+	flag(cc.Private, "private", cc.PrivateFields)
+	flag(cc.NoCache, "no-cache", cc.NoCacheFields)
+	flag(cc.NoStore, "no-store", nil)
+	flag(cc.NoTransform, "no-transform", nil)
+	flag(cc.OnlyIfCached, "only-if-cached", nil)
+	flag(cc.MustRevalidate, "must-revalidate", nil)
+	flag(cc.ProxyRevalidate, "proxy-revalidate", nil)
+	flag(cc.MustUnderstand, "must-understand", nil)
+	flag(cc.Immutable, "immutable", nil)
+	seconds(cc.MaxAge, "max-age")
+	seconds(cc.SMaxAge, "s-maxage")
+	if cc.MaxStale == AnyStale {
+		directives = append(directives, "max-stale")
+	} else {
+		seconds(cc.MaxStale, "max-stale")
+	}
+	seconds(cc.MinFresh, "min-fresh")
+	seconds(cc.StaleWhileRevalidate, "stale-while-revalidate")
+	seconds(cc.StaleIfError, "stale-if-error")
+
+	names := make([]string, 0, len(cc.Extensions))
+	for name := range cc.Extensions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if value := cc.Extensions[name]; value != "" {
+			directives = append(directives, name+"="+quote(value))
+		} else {
+			directives = append(directives, name)
+		}
+	}
+
+	return strings.Join(directives, ", ")
+}
+
+func (h Headers) CacheControl() (CacheControl, error) {
+	return ParseCacheControl(h.Get("Cache-Control"))
+}
@@ -0,0 +1,43 @@
+package headers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCacheControl(t *testing.T) {
+	// Test: Response directives
+	cc, err := ParseCacheControl(`max-age=60, Private="set-cookie, x-user", must-revalidate, community="UCI"`)
+	require.NoError(t, err)
+	assert.Equal(t, 60, cc.MaxAge)
+	assert.Equal(t, -1, cc.SMaxAge)
+	assert.True(t, cc.Private)
+	assert.Equal(t, []string{"set-cookie", "x-user"}, cc.PrivateFields)
+	assert.True(t, cc.MustRevalidate)
+	assert.False(t, cc.NoStore)
+	assert.Equal(t, map[string]string{"community": "UCI"}, cc.Extensions)
+
+	// Test: Formatting
+	assert.Equal(t, `private="set-cookie, x-user", must-revalidate, max-age=60, community=UCI`, cc.String())
+
+	// Test: max-stale without a value
+	cc, err = ParseCacheControl("max-stale, no-cache")
+	require.NoError(t, err)
+	assert.Equal(t, AnyStale, cc.MaxStale)
+	assert.Equal(t, "no-cache, max-stale", cc.String())
+
+	// Test: Built from scratch
+	cc = NewCacheControl()
+	cc.Public = true
+	cc.MaxAge = 31536000
+	cc.Immutable = true
+	assert.Equal(t, "public, immutable, max-age=31536000", cc.String())
+
+	// Test: Invalid delta-seconds
+	_, err = ParseCacheControl("max-age=soon")
+	require.Error(t, err)
+	_, err = ParseCacheControl("s-maxage=-1")
+	require.Error(t, err)
+}
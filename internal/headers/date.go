@@ -0,0 +1,39 @@
+package headers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	IMFFixdate  = "Mon, 02 Jan 2006 15:04:05 GMT"
+	rfc850Date  = "Monday, 02-Jan-06 15:04:05 GMT"
+	asctimeDate = "Mon Jan _2 15:04:05 2006"
+)
+
+// ParseHTTPDate parses an HTTP-date in the IMF-fixdate format or either of
+// the obsolete RFC 850 and asctime formats recipients must accept.
+func ParseHTTPDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{IMFFixdate, rfc850Date, asctimeDate} {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Error: invalid HTTP-date %q", s)
+}
+
+// FormatHTTPDate formats t as an IMF-fixdate.
+func FormatHTTPDate(t time.Time) string {
+	return t.UTC().Format(IMFFixdate)
+}
+
+func (h Headers) Time(key string) (time.Time, error) {
+	return ParseHTTPDate(h.Get(key))
+}
+
+func (h Headers) SetTime(key string, t time.Time) {
+	h.Replace(key, FormatHTTPDate(t))
+}
@@ -0,0 +1,39 @@
+package headers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHTTPDate(t *testing.T) {
+	want := time.Date(1994, time.November, 6, 8, 49, 37, 0, time.UTC)
+
+	// Test: All three formats
+	for _, s := range []string{
+		"Sun, 06 Nov 1994 08:49:37 GMT",
+		"Sunday, 06-Nov-94 08:49:37 GMT",
+		"Sun Nov  6 08:49:37 1994",
+	} {
+		got, err := ParseHTTPDate(s)
+		require.NoError(t, err, s)
+		assert.True(t, want.Equal(got), s)
+	}
+
+	// Test: Invalid date
+	_, err := ParseHTTPDate("yesterday")
+	require.Error(t, err)
+
+	// Test: Formatting converts to GMT
+	local := want.In(time.FixedZone("UTC+2", 2*60*60))
+	assert.Equal(t, "Sun, 06 Nov 1994 08:49:37 GMT", FormatHTTPDate(local))
+
+	// Test: Accessors on Headers
+	h := NewHeaders()
+	h.SetTime("Last-Modified", want)
+	got, err := h.Time("Last-Modified")
+	require.NoError(t, err)
+	assert.True(t, want.Equal(got))
+}
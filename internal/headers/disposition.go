@@ -0,0 +1,96 @@
+package headers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ContentDisposition is a parsed Content-Disposition value. Params holds the
+// parameters as sent, Filename the best filename found in them.
+type ContentDisposition struct {
+	Type     string
+	Filename string
+	Params   map[string]string
+}
+
+// ParseContentDisposition parses a Content-Disposition value, preferring the
+// RFC 5987 encoded filename* parameter over filename.
+func ParseContentDisposition(s string) (ContentDisposition, error) {
+	typ, params, _ := strings.Cut(s, ";")
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if !isToken(typ) {
+		return ContentDisposition{}, fmt.Errorf("Error: invalid disposition type %q", typ)
+	}
+
+	parsed, err := parseParams(params)
+	if err != nil {
+		return ContentDisposition{}, err
+	}
+
+	cd := ContentDisposition{Type: typ, Filename: parsed["filename"], Params: parsed}
+	if encoded, ok := parsed["filename*"]; ok {
+		filename, err := decodeExtValue(encoded)
+		if err != nil {
+			return ContentDisposition{}, err
+		}
+		cd.Filename = filename
+	}
+	return cd, nil
+}
+
+// decodeExtValue decodes an RFC 5987 ext-value, a charset, language and
+// percent-encoded value separated by quotes. Only UTF-8 is supported.
+func decodeExtValue(s string) (string, error) {
+	charset, rest, ok := strings.Cut(s, "'")
+	if !ok {
+		return "", fmt.Errorf("Error: invalid ext-value %q", s)
+	}
+	_, value, ok := strings.Cut(rest, "'")
+	if !ok {
+		return "", fmt.Errorf("Error: invalid ext-value %q", s)
+	}
+	if !strings.EqualFold(charset, "utf-8") {
+		return "", fmt.Errorf("Error: unsupported ext-value charset %q", charset)
+	}
+	return url.PathUnescape(value)
+}
+
+// FormatContentDisposition returns a Content-Disposition value for
+// filename. Names that aren't plain ASCII also get a filename* parameter,
+// with an ASCII fallback in filename.
+func FormatContentDisposition(typ, filename string) string {
+	if filename == "" {
+		return typ
+	}
+
+	fallback := make([]byte, 0, len(filename))
+	ascii := true
+	for _, r := range filename {
+		if r < 0x20 || r > 0x7e {
+			ascii = false
+			fallback = append(fallback, '_')
+			continue
+		}
+		fallback = append(fallback, byte(r))
+	}
+
+	value := typ + `; filename="` + quoteEscaper.Replace(string(fallback)) + `"`
+	if !ascii {
+		value += "; filename*=UTF-8''" + encodeExtValue(filename)
+	}
+	return value
+}
+
+func encodeExtValue(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAlphaNumeric(c) || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,36 @@
+package headers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentDisposition(t *testing.T) {
+	// Test: Plain filename
+	cd, err := ParseContentDisposition(`Attachment; filename="report 2024.pdf"`)
+	require.NoError(t, err)
+	assert.Equal(t, "attachment", cd.Type)
+	assert.Equal(t, "report 2024.pdf", cd.Filename)
+
+	// Test: filename* is preferred
+	cd, err = ParseContentDisposition(`attachment; filename="na_ve.txt"; filename*=UTF-8''na%C3%AFve.txt`)
+	require.NoError(t, err)
+	assert.Equal(t, "naïve.txt", cd.Filename)
+	assert.Equal(t, "na_ve.txt", cd.Params["filename"])
+
+	// Test: Unsupported charset
+	_, err = ParseContentDisposition(`attachment; filename*=ISO-8859-1'en'%A3%20rates`)
+	require.Error(t, err)
+
+	// Test: Formatting ASCII names
+	assert.Equal(t, `inline; filename="a \"b\".txt"`, FormatContentDisposition("inline", `a "b".txt`))
+
+	// Test: Formatting non-ASCII names round trips
+	value := FormatContentDisposition("attachment", "naïve €.txt")
+	assert.Equal(t, `attachment; filename="na_ve _.txt"; filename*=UTF-8''na%C3%AFve%20%E2%82%AC.txt`, value)
+	cd, err = ParseContentDisposition(value)
+	require.NoError(t, err)
+	assert.Equal(t, "naïve €.txt", cd.Filename)
+}
@@ -7,7 +7,7 @@ import (
 	"unicode"
 )
 
-type Headers map[string]string
+type Headers map[string][]string
 
 func NewHeaders() Headers {
 	return Headers{}
@@ -75,22 +75,40 @@ func isValidKey(data []byte) bool {
 	return true
 }
 
+// Set adds value to key, combining it with any existing value into a
+// comma-separated list.
 func (h Headers) Set(key, value string) {
 	key = strings.ToLower(key)
-	if v, ok := h[key]; ok {
-		value = v + ", " + value
+	if values := h[key]; len(values) > 0 {
+		values[len(values)-1] += ", " + value
+		return
 	}
-	h[key] = value
+	h[key] = []string{value}
 }
 
+// Add adds value to key as a separate field line, for fields such as
+// Set-Cookie that can't be combined into a list.
+func (h Headers) Add(key, value string) {
+	key = strings.ToLower(key)
+	h[key] = append(h[key], value)
+}
+
+// Get returns the value of key, the field lines added for it joined as a
+// list. Use Values for fields that can't be combined.
 func (h Headers) Get(key string) string {
+	key = strings.ToLower(key)
+	return strings.Join(h[key], ", ")
+}
+
+// Values returns the field lines of key.
+func (h Headers) Values(key string) []string {
 	key = strings.ToLower(key)
 	return h[key]
 }
 
 func (h Headers) Replace(key, value string) {
 	key = strings.ToLower(key)
-	h[key] = value
+	h[key] = []string{value}
 }
 
 func (h Headers) Delete(key string) {
@@ -64,3 +64,23 @@ func TestParseFieldLine(t *testing.T) {
 	assert.Equal(t, 0, n)
 	assert.False(t, done)
 }
+
+func TestHeadersValues(t *testing.T) {
+	// Test: Set combines values into a list
+	h := NewHeaders()
+	h.Set("Accept", "text/html")
+	h.Set("accept", "*/*")
+	assert.Equal(t, "text/html, */*", h.Get("Accept"))
+	assert.Equal(t, []string{"text/html, */*"}, h.Values("Accept"))
+
+	// Test: Add keeps separate field lines
+	h.Add("Set-Cookie", "a=1; Expires=Wed, 21 Oct 2026 07:28:00 GMT")
+	h.Add("set-cookie", "b=2")
+	assert.Equal(t, []string{"a=1; Expires=Wed, 21 Oct 2026 07:28:00 GMT", "b=2"}, h.Values("Set-Cookie"))
+
+	// Test: Replace and Delete drop every line
+	h.Replace("Set-Cookie", "c=3")
+	assert.Equal(t, []string{"c=3"}, h.Values("Set-Cookie"))
+	h.Delete("Set-Cookie")
+	assert.Empty(t, h.Values("Set-Cookie"))
+}
@@ -0,0 +1,65 @@
+package headers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Link is one link-value of an RFC 8288 Link header.
+type Link struct {
+	URI    string
+	Rel    string
+	Params map[string]string
+}
+
+func ParseLink(s string) ([]Link, error) {
+	var links []Link
+	for _, part := range splitList(s, ',') {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.HasPrefix(part, "<") {
+			return nil, fmt.Errorf("Error: invalid link-value %q", part)
+		}
+		end := strings.IndexByte(part, '>')
+		if end == -1 {
+			return nil, fmt.Errorf("Error: invalid link-value %q", part)
+		}
+
+		params, err := parseParams(part[end+1:])
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, Link{URI: part[1:end], Rel: params["rel"], Params: params})
+	}
+	return links, nil
+}
+
+func (l Link) String() string {
+	var sb strings.Builder
+	sb.WriteString("<" + l.URI + ">")
+	if l.Rel != "" {
+		sb.WriteString(`; rel="` + quoteEscaper.Replace(l.Rel) + `"`)
+	}
+	names := make([]string, 0, len(l.Params))
+	for name := range l.Params {
+		if name != "rel" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		sb.WriteString("; " + name + "=" + quote(l.Params[name]))
+	}
+	return sb.String()
+}
+
+func FormatLinks(links []Link) string {
+	values := make([]string, len(links))
+	for i, l := range links {
+		values[i] = l.String()
+	}
+	return strings.Join(values, ", ")
+}
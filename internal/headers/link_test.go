@@ -0,0 +1,28 @@
+package headers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLink(t *testing.T) {
+	// Test: Multiple links with commas inside the URI and quotes
+	links, err := ParseLink(`<https://api.example.com/items?page=2&tags=a,b>; rel="next", </items?page=9>; rel=last; title="Last, final"`)
+	require.NoError(t, err)
+	require.Len(t, links, 2)
+	assert.Equal(t, "https://api.example.com/items?page=2&tags=a,b", links[0].URI)
+	assert.Equal(t, "next", links[0].Rel)
+	assert.Equal(t, "/items?page=9", links[1].URI)
+	assert.Equal(t, "last", links[1].Rel)
+	assert.Equal(t, "Last, final", links[1].Params["title"])
+
+	// Test: Formatting
+	assert.Equal(t, `</items?page=9>; rel="last"; title="Last, final"`, links[1].String())
+	assert.Equal(t, `</a>; rel="prev", </c>; rel="next"`, FormatLinks([]Link{{URI: "/a", Rel: "prev"}, {URI: "/c", Rel: "next"}}))
+
+	// Test: Missing angle brackets
+	_, err = ParseLink(`https://example.com; rel=next`)
+	require.Error(t, err)
+}
@@ -0,0 +1,53 @@
+package headers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MediaType is a parsed Content-Type value. Type, Subtype and parameter names
+// are lowercase.
+type MediaType struct {
+	Type    string
+	Subtype string
+	Params  map[string]string
+}
+
+func ParseMediaType(s string) (MediaType, error) {
+	essence, params, _ := strings.Cut(s, ";")
+	typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(essence)), "/")
+	if !ok || !isToken(typ) || !isToken(subtype) {
+		return MediaType{}, fmt.Errorf("Error: invalid media type %q", essence)
+	}
+
+	parsed, err := parseParams(params)
+	if err != nil {
+		return MediaType{}, err
+	}
+
+	return MediaType{Type: typ, Subtype: subtype, Params: parsed}, nil
+}
+
+// Essence returns the type and subtype without parameters.
+func (m MediaType) Essence() string {
+	return m.Type + "/" + m.Subtype
+}
+
+func (m MediaType) String() string {
+	var sb strings.Builder
+	sb.WriteString(m.Essence())
+	names := make([]string, 0, len(m.Params))
+	for name := range m.Params {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		sb.WriteString("; " + name + "=" + quote(m.Params[name]))
+	}
+	return sb.String()
+}
+
+func (h Headers) ContentType() (MediaType, error) {
+	return ParseMediaType(h.Get("Content-Type"))
+}
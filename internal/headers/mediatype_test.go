@@ -0,0 +1,34 @@
+package headers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMediaType(t *testing.T) {
+	// Test: Type with parameters
+	m, err := ParseMediaType(`Text/HTML; Charset=utf-8; title="a \"b\"; c"`)
+	require.NoError(t, err)
+	assert.Equal(t, "text/html", m.Essence())
+	assert.Equal(t, map[string]string{"charset": "utf-8", "title": `a "b"; c`}, m.Params)
+
+	// Test: Formatting quotes non-token values
+	assert.Equal(t, `text/html; charset=utf-8; title="a \"b\"; c"`, m.String())
+
+	// Test: Accessor on Headers
+	h := NewHeaders()
+	h.Set("Content-Type", "application/json")
+	m, err = h.ContentType()
+	require.NoError(t, err)
+	assert.Equal(t, "json", m.Subtype)
+
+	// Test: Invalid media types
+	_, err = ParseMediaType("text")
+	require.Error(t, err)
+	_, err = ParseMediaType("text/html; charset")
+	require.NoError(t, err)
+	_, err = ParseMediaType(`text/html; title="open`)
+	require.Error(t, err)
+}
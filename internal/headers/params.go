@@ -0,0 +1,91 @@
+package headers
+
+import (
+	"fmt"
+	"strings"
+)
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func isToken(s string) bool {
+	return s != "" && isValidKey([]byte(s))
+}
+
+// quote returns s unchanged when it's a token and as a quoted-string
+// otherwise.
+func quote(s string) string {
+	if isToken(s) {
+		return s
+	}
+	return `"` + quoteEscaper.Replace(s) + `"`
+}
+
+// splitList splits s on sep outside of quoted strings and <> references.
+func splitList(s string, sep byte) []string {
+	var parts []string
+	inQuote, inRef, escaped := false, false, false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inQuote && c == '\\':
+			escaped = true
+		case c == '"' && !inRef:
+			inQuote = !inQuote
+		case c == '<' && !inQuote:
+			inRef = true
+		case c == '>' && !inQuote:
+			inRef = false
+		case c == sep && !inQuote && !inRef:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseParams parses ";"-separated name=value pairs, lowercasing the names
+// and unquoting the values. Names without a value map to "".
+func parseParams(s string) (map[string]string, error) {
+	params := map[string]string{}
+	for _, part := range splitList(s, ';') {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, _ := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !isToken(name) {
+			return nil, fmt.Errorf("Error: invalid parameter name %q", name)
+		}
+
+		value, err := unquote(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		params[name] = value
+	}
+	return params, nil
+}
+
+func unquote(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, nil
+	}
+	if len(s) < 2 || !strings.HasSuffix(s, `"`) {
+		return "", fmt.Errorf("Error: unterminated quoted string %s", s)
+	}
+
+	var sb strings.Builder
+	s = s[1 : len(s)-1]
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String(), nil
+}
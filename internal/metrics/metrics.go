@@ -0,0 +1,292 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+)
+
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are latency buckets in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type collector interface {
+	write(w io.Writer) error
+}
+
+// Registry holds metrics and renders them in the Prometheus text exposition
+// format, in registration order.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Registry) Handle(w *response.Writer, req *request.Request) {
+	var body strings.Builder
+	r.WriteText(&body)
+
+	w.WriteStatusLine(response.StatusOK)
+	h := response.GetDefaultHeaders(body.Len())
+	h.Replace("Content-Type", ContentType)
+	h.Set("Cache-Control", "no-store")
+	w.WriteHeaders(h)
+	w.WriteBody([]byte(body.String()))
+}
+
+// family is the shared state of a metric with its label names and one series
+// per combination of label values.
+type family struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	buckets     []uint64
+	count       uint64
+}
+
+func newFamily(name, help, typ string, labels []string) *family {
+	return &family{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		series: map[string]*series{},
+	}
+}
+
+// get returns the series for labelValues, which must be called with f.mu
+// held.
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: slices.Clone(labelValues)}
+		f.series[key] = s
+	}
+	return s
+}
+
+// sorted returns the series ordered by label values, which must be called
+// with f.mu held.
+func (f *family) sorted() []*series {
+	all := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		all = append(all, s)
+	}
+	slices.SortFunc(all, func(a, b *series) int {
+		return slices.Compare(a.labelValues, b.labelValues)
+	})
+	return all
+}
+
+func (f *family) writeHeader(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, helpEscaper.Replace(f.help), f.name, f.typ)
+	return err
+}
+
+func (f *family) labelString(values []string, extraName, extraValue string) string {
+	var pairs []string
+	for i, name := range f.labels {
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(values[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type Counter struct {
+	f *family
+}
+
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{f: newFamily(name, help, "counter", labels)}
+	r.register(c)
+	return c
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the counter, ignoring negative values.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	c.f.get(labelValues).value += v
+}
+
+func (c *Counter) write(w io.Writer) error {
+	return writeValues(w, c.f)
+}
+
+type Gauge struct {
+	f *family
+}
+
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{f: newFamily(name, help, "gauge", labels)}
+	r.register(g)
+	return g
+}
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+	g.f.get(labelValues).value = v
+}
+
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+	g.f.get(labelValues).value += v
+}
+
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *Gauge) write(w io.Writer) error {
+	return writeValues(w, g.f)
+}
+
+func writeValues(w io.Writer, f *family) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.writeHeader(w); err != nil {
+		return err
+	}
+	for _, s := range f.sorted() {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", f.name, f.labelString(s.labelValues, "", ""), formatFloat(s.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Histogram struct {
+	f       *family
+	buckets []float64
+}
+
+// NewHistogram registers a histogram with the given upper bounds, which must
+// be sorted. The +Inf bucket is implicit.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		f:       newFamily(name, help, "histogram", labels),
+		buckets: buckets,
+	}
+	r.register(h)
+	return h
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+
+	s := h.f.get(labelValues)
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(h.buckets))
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.value += v
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+
+	if err := h.f.writeHeader(w); err != nil {
+		return err
+	}
+	for _, s := range h.f.sorted() {
+		for i, bound := range h.buckets {
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.f.name, h.f.labelString(s.labelValues, "le", formatFloat(bound)), s.buckets[i]); err != nil {
+				return err
+			}
+		}
+		labels := h.f.labelString(s.labelValues, "", "")
+		_, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.f.name, h.f.labelString(s.labelValues, "le", "+Inf"), s.count,
+			h.f.name, labels, formatFloat(s.value),
+			h.f.name, labels, s.count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("requests_total", "Requests served.", "method", "status")
+	active := r.NewGauge("connections_active", "Open connections.")
+	latency := r.NewHistogram("latency_seconds", "Request latency.", []float64{0.1, 1}, "route")
+
+	requests.Inc("GET", "200")
+	requests.Inc("GET", "200")
+	requests.Add(-5, "GET", "200")
+	requests.Inc("POST", "500")
+	active.Inc()
+	active.Inc()
+	active.Dec()
+	latency.Observe(0.05, `/a"b`)
+	latency.Observe(0.5, `/a"b`)
+	latency.Observe(3, `/a"b`)
+
+	// Test: Text exposition format
+	var buff bytes.Buffer
+	require.NoError(t, r.WriteText(&buff))
+	assert.Equal(t, `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{method="GET",status="200"} 2
+requests_total{method="POST",status="500"} 1
+# HELP connections_active Open connections.
+# TYPE connections_active gauge
+connections_active 1
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a\"b",le="0.1"} 1
+latency_seconds_bucket{route="/a\"b",le="1"} 2
+latency_seconds_bucket{route="/a\"b",le="+Inf"} 3
+latency_seconds_sum{route="/a\"b"} 3.55
+latency_seconds_count{route="/a\"b"} 3
+`, buff.String())
+
+	// Test: Wrong number of label values
+	assert.Panics(t, func() { requests.Inc("GET") })
+
+	// Test: Handler
+	req, err := request.RequestFromReader(strings.NewReader("GET /metrics HTTP/1.1\r\n\r\n"))
+	require.NoError(t, err)
+	buff.Reset()
+	r.Handle(response.NewResponseWriter(&buff), req)
+	out := buff.String()
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n"))
+	assert.Contains(t, out, "content-type: text/plain; version=0.0.4; charset=utf-8\r\n")
+	assert.Contains(t, out, "\r\n\r\n# HELP requests_total Requests served.\n")
+}
@@ -0,0 +1,82 @@
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/Quak1/learn-http-go/internal/problem"
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/Quak1/learn-http-go/internal/server"
+)
+
+// ServerConfig returns a copy of base that asks clients for a certificate and
+// verifies any certificate sent against clientCAs. Clients without one are
+// still accepted, routes that need one are guarded with Require.
+func ServerConfig(base *tls.Config, clientCAs *x509.CertPool) *tls.Config {
+	config := base.Clone()
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	config.ClientCAs = clientCAs
+	return config
+}
+
+// LoadCertPool reads PEM encoded CA certificates from the given files.
+func LoadCertPool(files ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("Error: no certificates found in %s", file)
+		}
+	}
+	return pool, nil
+}
+
+// Require only lets through requests with a client certificate verified
+// during the handshake. CAs narrows the accepted issuers for a route and
+// Authorize can further check the verified chain, leaf first.
+type Require struct {
+	CAs       *x509.CertPool
+	Authorize func(chain []*x509.Certificate) bool
+}
+
+func (r *Require) Wrap(next server.Handler) server.Handler {
+	return func(w *response.Writer, req *request.Request) {
+		chain := req.VerifiedChain()
+		if chain == nil {
+			problem.Write(w, req, problem.New(response.StatusForbidden, "a client certificate is required"))
+			return
+		}
+
+		if r.CAs != nil && !r.issuedBy(req.TLS.PeerCertificates) {
+			problem.Write(w, req, problem.New(response.StatusForbidden, "client certificate not issued by an accepted CA"))
+			return
+		}
+
+		if r.Authorize != nil && !r.Authorize(chain) {
+			problem.Write(w, req, problem.New(response.StatusForbidden, "client certificate not authorized"))
+			return
+		}
+
+		next(w, req)
+	}
+}
+
+func (r *Require) issuedBy(peers []*x509.Certificate) bool {
+	intermediates := x509.NewCertPool()
+	for _, cert := range peers[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := peers[0].Verify(x509.VerifyOptions{
+		Roots:         r.CAs,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err == nil
+}
@@ -0,0 +1,172 @@
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/Quak1/learn-http-go/internal/router"
+	"github.com/Quak1/learn-http-go/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newAuthority(t *testing.T, name string) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &authority{cert: cert, key: key}
+}
+
+func (a *authority) issueClient(t *testing.T, name string, dnsNames ...string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func whoami(w *response.Writer, req *request.Request) {
+	body := "anonymous"
+	if chain := req.VerifiedChain(); chain != nil {
+		body = chain[0].Subject.CommonName + " " + strings.Join(chain[0].DNSNames, ",")
+	}
+	w.WriteStatusLine(response.StatusOK)
+	w.WriteHeaders(response.GetDefaultHeaders(len(body)))
+	w.WriteBody([]byte(body))
+}
+
+func TestMutualTLS(t *testing.T) {
+	services := newAuthority(t, "services CA")
+	admins := newAuthority(t, "admins CA")
+	rogue := newAuthority(t, "rogue CA")
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(services.cert)
+	clientCAs.AddCert(admins.cert)
+	adminCAs := x509.NewCertPool()
+	adminCAs.AddCert(admins.cert)
+
+	serverCert, err := server.SelfSignedCertificate("localhost")
+	require.NoError(t, err)
+	serverRoots := x509.NewCertPool()
+	serverRoots.AddCert(serverCert.Leaf)
+
+	r := router.New()
+	r.Get("/public", whoami)
+	r.Get("/internal", (&Require{
+		Authorize: func(chain []*x509.Certificate) bool {
+			return slices.Contains(chain[0].DNSNames, "billing.svc.internal")
+		},
+	}).Wrap(whoami))
+	r.Get("/admin", (&Require{CAs: adminCAs}).Wrap(whoami))
+
+	config := ServerConfig(&tls.Config{Certificates: []tls.Certificate{serverCert}}, clientCAs)
+	s, err := server.ServeTLS(0, r.Handle, config)
+	require.NoError(t, err)
+	defer s.Close()
+
+	get := func(path string, certs ...tls.Certificate) (string, error) {
+		conn, err := tls.Dial("tcp", s.Addr().String(), &tls.Config{
+			RootCAs:    serverRoots,
+			ServerName: "localhost",
+			// always present the certificate, even when the server doesn't list its CA
+			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				if len(certs) == 0 {
+					return &tls.Certificate{}, nil
+				}
+				return &certs[0], nil
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+
+		if _, err := conn.Write([]byte("GET " + path + " HTTP/1.1\r\nHost: localhost\r\n\r\n")); err != nil {
+			return "", err
+		}
+		out, err := io.ReadAll(conn)
+		return string(out), err
+	}
+
+	billing := services.issueClient(t, "billing", "billing.svc.internal")
+	reports := services.issueClient(t, "reports", "reports.svc.internal")
+	alice := admins.issueClient(t, "alice")
+
+	// Test: Public route without a certificate
+	out, err := get("/public")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(out, "anonymous"))
+
+	// Test: Verified chain is exposed on the request
+	out, err = get("/public", billing)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(out, "billing billing.svc.internal"))
+
+	// Test: Protected route without a certificate
+	out, err = get("/internal")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 403 Forbidden\r\n"))
+
+	// Test: Authorize callback
+	out, err = get("/internal", billing)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n"))
+	out, err = get("/internal", reports)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 403 Forbidden\r\n"))
+
+	// Test: Route restricted to one CA
+	out, err = get("/admin", billing)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 403 Forbidden\r\n"))
+	out, err = get("/admin", alice)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(out, "alice "))
+
+	// Test: Certificates from unknown CAs fail the handshake
+	out, err = get("/public", rogue.issueClient(t, "mallory"))
+	assert.False(t, err == nil && strings.HasPrefix(out, "HTTP/1.1"))
+}
@@ -0,0 +1,213 @@
+package negotiate
+
+import (
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+)
+
+// Spec is one element of an Accept-family header. Value is lowercased and
+// Params holds the parameters other than q.
+type Spec struct {
+	Value  string
+	Params map[string]string
+	Q      float64
+}
+
+// Parse splits an Accept, Accept-Language or Accept-Charset header into its
+// elements, ordered by descending q-value.
+func Parse(header string) []Spec {
+	var specs []Spec
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+
+		spec := Spec{Value: value, Params: map[string]string{}, Q: 1}
+		for _, param := range strings.Split(params, ";") {
+			key, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok {
+				continue
+			}
+			key = strings.ToLower(strings.TrimSpace(key))
+			v = strings.Trim(strings.TrimSpace(v), `"`)
+			if key == "q" {
+				q, err := strconv.ParseFloat(v, 64)
+				if err != nil || q < 0 || q > 1 {
+					q = 0
+				}
+				spec.Q = q
+				continue
+			}
+			spec.Params[key] = v
+		}
+		specs = append(specs, spec)
+	}
+
+	slices.SortStableFunc(specs, func(a, b Spec) int {
+		switch {
+		case a.Q > b.Q:
+			return -1
+		case a.Q < b.Q:
+			return 1
+		}
+		return 0
+	})
+	return specs
+}
+
+// MediaType returns the offer preferred by an Accept header, each offer
+// taking the q-value of the most specific media range matching it. Ties go
+// to the earlier offer and "" means none is acceptable.
+func MediaType(accept string, offers []string) string {
+	return best(accept, offers, func(spec Spec, offer string) int {
+		offerSpec := Parse(offer)
+		if len(offerSpec) == 0 {
+			return -1
+		}
+		offerType, offerSubtype, _ := strings.Cut(offerSpec[0].Value, "/")
+		rangeType, rangeSubtype, _ := strings.Cut(spec.Value, "/")
+
+		switch {
+		case rangeType == "*" && rangeSubtype == "*":
+			return 0
+		case rangeType != offerType:
+			return -1
+		case rangeSubtype == "*":
+			return 1
+		case rangeSubtype != offerSubtype:
+			return -1
+		}
+
+		for k, v := range spec.Params {
+			if !strings.EqualFold(offerSpec[0].Params[k], v) {
+				return -1
+			}
+		}
+		return 2 + len(spec.Params)
+	})
+}
+
+// Language returns the offer preferred by an Accept-Language header, a range
+// matching a tag equal to it or starting with it followed by "-".
+func Language(acceptLanguage string, offers []string) string {
+	return best(acceptLanguage, offers, func(spec Spec, offer string) int {
+		offer = strings.ToLower(offer)
+		switch {
+		case spec.Value == "*":
+			return 0
+		case spec.Value == offer || strings.HasPrefix(offer, spec.Value+"-"):
+			return len(spec.Value)
+		}
+		return -1
+	})
+}
+
+// Charset returns the offer preferred by an Accept-Charset header.
+func Charset(acceptCharset string, offers []string) string {
+	return best(acceptCharset, offers, func(spec Spec, offer string) int {
+		switch {
+		case spec.Value == "*":
+			return 0
+		case spec.Value == strings.ToLower(offer):
+			return 1
+		}
+		return -1
+	})
+}
+
+// best picks the offer with the highest q-value, taken from the matching
+// spec with the highest specificity. A missing header accepts anything.
+func best(header string, offers []string, specificity func(spec Spec, offer string) int) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	specs := Parse(header)
+	if len(specs) == 0 {
+		return offers[0]
+	}
+
+	bestOffer := ""
+	bestQ := 0.0
+	for _, offer := range offers {
+		q := 0.0
+		mostSpecific := -1
+		for _, spec := range specs {
+			if s := specificity(spec, offer); s > mostSpecific {
+				mostSpecific = s
+				q = spec.Q
+			}
+		}
+		if q > bestQ {
+			bestOffer = offer
+			bestQ = q
+		}
+	}
+	return bestOffer
+}
+
+// Offers lists the representations a handler can produce, empty dimensions
+// being left out of negotiation.
+type Offers struct {
+	Types     []string
+	Languages []string
+	Charsets  []string
+}
+
+type Result struct {
+	Type     string
+	Language string
+	Charset  string
+}
+
+// Negotiate picks the representation to send and adds the matching Vary
+// header to the response. When an offered dimension has no acceptable value
+// it replies 406 listing the offered types and returns false.
+func Negotiate(w *response.Writer, req *request.Request, offers Offers) (Result, bool) {
+	var vary []string
+	var result Result
+	acceptable := true
+
+	if len(offers.Types) > 0 {
+		vary = append(vary, "Accept")
+		result.Type = MediaType(req.Headers.Get("Accept"), offers.Types)
+		acceptable = acceptable && result.Type != ""
+	}
+	if len(offers.Languages) > 0 {
+		vary = append(vary, "Accept-Language")
+		result.Language = Language(req.Headers.Get("Accept-Language"), offers.Languages)
+		acceptable = acceptable && result.Language != ""
+	}
+	if len(offers.Charsets) > 0 {
+		vary = append(vary, "Accept-Charset")
+		result.Charset = Charset(req.Headers.Get("Accept-Charset"), offers.Charsets)
+		acceptable = acceptable && result.Charset != ""
+	}
+
+	if len(vary) > 0 {
+		w.AddHeaderHook(func(status response.StatusCode, h headers.Headers, body io.Writer) io.WriteCloser {
+			h.Set("Vary", strings.Join(vary, ", "))
+			return nil
+		})
+	}
+
+	if acceptable {
+		return result, true
+	}
+
+	body := "Not Acceptable\n"
+	for _, t := range offers.Types {
+		body += t + "\n"
+	}
+	w.WriteStatusLine(response.StatusNotAcceptable)
+	w.WriteHeaders(response.GetDefaultHeaders(len(body)))
+	w.WriteBody([]byte(body))
+	return Result{}, false
+}
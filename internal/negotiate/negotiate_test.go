@@ -0,0 +1,106 @@
+package negotiate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	// Test: Ordered by q-value with parameters kept
+	specs := Parse(`text/html;level=1;q=0.5, Application/JSON, text/*;q=0.8, image/png;q=bad`)
+	require.Len(t, specs, 4)
+	assert.Equal(t, "application/json", specs[0].Value)
+	assert.Equal(t, "text/*", specs[1].Value)
+	assert.Equal(t, Spec{Value: "text/html", Params: map[string]string{"level": "1"}, Q: 0.5}, specs[2])
+	assert.Equal(t, 0.0, specs[3].Q)
+
+	// Test: Empty header
+	assert.Empty(t, Parse(""))
+}
+
+func TestMediaType(t *testing.T) {
+	offers := []string{"application/json", "text/html", "text/plain"}
+
+	// Test: Missing header accepts the first offer
+	assert.Equal(t, "application/json", MediaType("", offers))
+
+	// Test: Highest q-value wins
+	assert.Equal(t, "text/html", MediaType("text/html, application/json;q=0.9", offers))
+
+	// Test: Most specific range sets the q-value
+	assert.Equal(t, "text/plain", MediaType("text/*, text/html;q=0, application/json;q=0.5", offers))
+
+	// Test: Wildcard
+	assert.Equal(t, "application/json", MediaType("*/*;q=0.1", offers))
+
+	// Test: Range parameters must match the offer
+	assert.Equal(t, "text/html; level=1", MediaType("text/html;level=1", []string{"text/html", "text/html; level=1"}))
+
+	// Test: Nothing acceptable
+	assert.Equal(t, "", MediaType("image/png", offers))
+	assert.Equal(t, "", MediaType("application/json;q=0", []string{"application/json"}))
+}
+
+func TestLanguage(t *testing.T) {
+	offers := []string{"en-US", "fr", "de-CH"}
+
+	// Test: Prefix range matches
+	assert.Equal(t, "de-CH", Language("de, en;q=0.5", offers))
+
+	// Test: Case insensitive exact match
+	assert.Equal(t, "en-US", Language("EN-us", offers))
+
+	// Test: Longer range is more specific than wildcard
+	assert.Equal(t, "fr", Language("*;q=0.5, en;q=0, fr", offers))
+	assert.Equal(t, "de-CH", Language("*, en;q=0, fr;q=0", offers))
+
+	// Test: Nothing acceptable
+	assert.Equal(t, "", Language("ja", offers))
+}
+
+func TestCharset(t *testing.T) {
+	// Test: Preferred charset
+	assert.Equal(t, "iso-8859-1", Charset("utf-8;q=0.5, ISO-8859-1", []string{"utf-8", "iso-8859-1"}))
+
+	// Test: Wildcard
+	assert.Equal(t, "utf-8", Charset("*", []string{"utf-8"}))
+}
+
+func TestNegotiate(t *testing.T) {
+	negotiate := func(raw string, offers Offers) (Result, bool, string) {
+		req, err := request.RequestFromReader(strings.NewReader(raw))
+		require.NoError(t, err)
+
+		var buff bytes.Buffer
+		w := response.NewResponseWriter(&buff)
+		result, ok := Negotiate(w, req, offers)
+		if ok {
+			w.WriteStatusLine(response.StatusOK)
+			w.WriteHeaders(response.GetDefaultHeaders(0))
+		}
+		return result, ok, buff.String()
+	}
+	offers := Offers{
+		Types:     []string{"application/json", "text/html"},
+		Languages: []string{"en", "es"},
+	}
+
+	// Test: Picks a representation and sets Vary
+	result, ok, out := negotiate("GET / HTTP/1.1\r\nAccept: text/html\r\nAccept-Language: es-MX, es;q=0.9\r\n\r\n", offers)
+	assert.True(t, ok)
+	assert.Equal(t, Result{Type: "text/html", Language: "es"}, result)
+	assert.Contains(t, out, "vary: Accept, Accept-Language\r\n")
+
+	// Test: 406 when a dimension can't be satisfied
+	_, ok, out = negotiate("GET / HTTP/1.1\r\nAccept: image/png\r\n\r\n", offers)
+	assert.False(t, ok)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 406 Not Acceptable\r\n"))
+	assert.Contains(t, out, "vary: Accept, Accept-Language\r\n")
+	assert.True(t, strings.HasSuffix(out, "application/json\ntext/html\n"))
+}
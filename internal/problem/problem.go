@@ -0,0 +1,133 @@
+package problem
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/Quak1/learn-http-go/internal/negotiate"
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+)
+
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details object. Extensions are added as
+// extra members of the JSON object.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     response.StatusCode
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+
+	// Headers are extra response headers, such as Allow for a 405.
+	Headers headers.Headers
+}
+
+// New returns a problem of the default "about:blank" type titled after the
+// status.
+func New(status response.StatusCode, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(int(status)),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("Error: %s: %s", p.Title, p.Detail)
+	}
+	return "Error: " + p.Title
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := map[string]any{}
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	if p.Type != "" {
+		members["type"] = p.Type
+	}
+	if p.Title != "" {
+		members["title"] = p.Title
+	}
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+// Handle adapts a handler that reports failures by returning a Problem.
+func Handle(h func(w *response.Writer, req *request.Request) *Problem) func(w *response.Writer, req *request.Request) {
+	return func(w *response.Writer, req *request.Request) {
+		if p := h(w, req); p != nil {
+			Write(w, req, p)
+		}
+	}
+}
+
+// Write renders p as problem+json, or as HTML when the request prefers it.
+// req may be nil when the request couldn't be parsed.
+func Write(w *response.Writer, req *request.Request, p *Problem) error {
+	contentType := ContentType
+	var body []byte
+	if req != nil && prefersHTML(req.Headers.Get("Accept")) {
+		contentType = "text/html; charset=utf-8"
+		body = renderHTML(p)
+	} else {
+		encoded, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		body = append(encoded, '\n')
+	}
+
+	if err := w.WriteStatusLine(p.Status); err != nil {
+		return err
+	}
+	h := response.GetDefaultHeaders(len(body))
+	h.Replace("Content-Type", contentType)
+	h.Set("Vary", "Accept")
+	for k, values := range p.Headers {
+		for _, v := range values {
+			h.Set(k, v)
+		}
+	}
+	if err := w.WriteHeaders(h); err != nil {
+		return err
+	}
+	_, err := w.WriteBody(body)
+	return err
+}
+
+func renderHTML(p *Problem) []byte {
+	title := html.EscapeString(fmt.Sprintf("%d %s", p.Status, p.Title))
+
+	var sb strings.Builder
+	sb.WriteString("<html>\n  <head>\n    <title>" + title + "</title>\n  </head>\n  <body>\n")
+	sb.WriteString("    <h1>" + html.EscapeString(p.Title) + "</h1>\n")
+	if p.Detail != "" {
+		sb.WriteString("    <p>" + html.EscapeString(p.Detail) + "</p>\n")
+	}
+	sb.WriteString("  </body>\n</html>\n")
+	return []byte(sb.String())
+}
+
+// prefersHTML reports whether text/html is accepted with a higher q-value
+// than JSON. Ties go to JSON.
+func prefersHTML(accept string) bool {
+	return negotiate.MediaType(accept, []string{ContentType, "application/json", "text/html"}) == "text/html"
+}
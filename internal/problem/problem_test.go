@@ -0,0 +1,77 @@
+package problem
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, accept string, p *Problem) string {
+	raw := "GET /orders/7 HTTP/1.1\r\n"
+	if accept != "" {
+		raw += "Accept: " + accept + "\r\n"
+	}
+	req, err := request.RequestFromReader(strings.NewReader(raw + "\r\n"))
+	require.NoError(t, err)
+
+	var buff bytes.Buffer
+	require.NoError(t, Write(response.NewResponseWriter(&buff), req, p))
+	return buff.String()
+}
+
+func TestProblem(t *testing.T) {
+	p := New(response.StatusNotFound, "order 7 doesn't exist")
+	p.Instance = "/orders/7"
+	p.Extensions = map[string]any{"order": 7}
+
+	// Test: JSON by default
+	out := render(t, "", p)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 404 Not Found\r\n"))
+	assert.Contains(t, out, "content-type: application/problem+json\r\n")
+	assert.Contains(t, out, "vary: Accept\r\n")
+
+	_, body, _ := strings.Cut(out, "\r\n\r\n")
+	var members map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &members))
+	assert.Equal(t, map[string]any{
+		"type":     "about:blank",
+		"title":    "Not Found",
+		"status":   float64(404),
+		"detail":   "order 7 doesn't exist",
+		"instance": "/orders/7",
+		"order":    float64(7),
+	}, members)
+
+	// Test: HTML for browsers
+	out = render(t, "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", p)
+	assert.Contains(t, out, "content-type: text/html; charset=utf-8\r\n")
+	assert.Contains(t, out, "<h1>Not Found</h1>")
+	assert.Contains(t, out, "<p>order 7 doesn&#39;t exist</p>")
+
+	// Test: JSON wins ties
+	out = render(t, "text/html, application/json", p)
+	assert.Contains(t, out, "content-type: application/problem+json\r\n")
+
+	// Test: Extra headers are sent
+	p = New(response.StatusMethodNotAllowed, "")
+	p.Headers = headers.Headers{"allow": {"GET, HEAD"}}
+	out = render(t, "", p)
+	assert.Contains(t, out, "allow: GET, HEAD\r\n")
+	assert.NotContains(t, out, "detail")
+
+	// Test: Handle renders returned problems
+	var buff bytes.Buffer
+	req, err := request.RequestFromReader(strings.NewReader("GET / HTTP/1.1\r\n\r\n"))
+	require.NoError(t, err)
+	Handle(func(w *response.Writer, req *request.Request) *Problem {
+		return New(response.StatusBadRequest, "")
+	})(response.NewResponseWriter(&buff), req)
+	assert.True(t, strings.HasPrefix(buff.String(), "HTTP/1.1 400 Bad Request\r\n"))
+}
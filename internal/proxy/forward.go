@@ -0,0 +1,148 @@
+package proxy
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+)
+
+type ForwardProxy struct {
+	// Empty AllowedHosts denies every host, entries starting with "*." match
+	// any subdomain.
+	AllowedHosts []string
+	AllowedPorts []int
+	DialTimeout  time.Duration
+
+	client *http.Client
+}
+
+func NewForwardProxy() *ForwardProxy {
+	return &ForwardProxy{
+		AllowedPorts: []int{80, 443},
+		DialTimeout:  10 * time.Second,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (f *ForwardProxy) Handle(w *response.Writer, req *request.Request) {
+	if req.RequestLine.Method == "CONNECT" {
+		f.handleConnect(w, req)
+		return
+	}
+
+	u, err := url.Parse(req.RequestLine.RequestTarget)
+	if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+		response.WriteError(w, response.StatusBadRequest, nil)
+		return
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	if !f.allowed(u.Hostname(), port) {
+		response.WriteError(w, response.StatusForbidden, nil)
+		return
+	}
+
+	resp, body, err := forward(f.client, u.String(), req)
+	if err != nil {
+		response.WriteError(w, response.StatusBadGateway, nil)
+		return
+	}
+
+	writeResponse(w, req, resp, body)
+}
+
+func (f *ForwardProxy) handleConnect(w *response.Writer, req *request.Request) {
+	host, port, err := net.SplitHostPort(req.RequestLine.RequestTarget)
+	if err != nil {
+		response.WriteError(w, response.StatusBadRequest, nil)
+		return
+	}
+	if !f.allowed(host, port) {
+		response.WriteError(w, response.StatusForbidden, nil)
+		return
+	}
+
+	upstream, err := net.DialTimeout("tcp", req.RequestLine.RequestTarget, f.DialTimeout)
+	if err != nil {
+		response.WriteError(w, response.StatusBadGateway, nil)
+		return
+	}
+
+	w.WriteStatusLine(response.StatusOK)
+	w.WriteHeaders(nil)
+
+	conn, buffered, err := w.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if len(buffered) > 0 {
+		if _, err := upstream.Write(buffered); err != nil {
+			conn.Close()
+			upstream.Close()
+			return
+		}
+	}
+
+	splice(conn, upstream)
+}
+
+func (f *ForwardProxy) allowed(host, port string) bool {
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return false
+	}
+	if len(f.AllowedPorts) > 0 && !slices.Contains(f.AllowedPorts, p) {
+		return false
+	}
+	host = strings.ToLower(host)
+	for _, allowed := range f.AllowedHosts {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splice copies bytes both ways between a and b until either side is done,
+// then closes both.
+func splice(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	cp := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		done <- struct{}{}
+	}
+
+	go cp(a, b)
+	go cp(b, a)
+	<-done
+
+	a.Close()
+	b.Close()
+	<-done
+}
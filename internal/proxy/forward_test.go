@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardProxy(t *testing.T) {
+	backend := newBackend("origin")
+	defer backend.Close()
+	u, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	// Test: Absolute-form GET is forwarded
+	f := NewForwardProxy()
+	f.AllowedPorts = []int{port}
+	f.AllowedHosts = []string{u.Hostname()}
+	req := newRequest(t, "GET "+backend.URL+"/coffee HTTP/1.1\r\nHost: "+u.Host+"\r\n\r\n")
+	var buff bytes.Buffer
+	f.Handle(response.NewResponseWriter(&buff), req)
+	assert.True(t, strings.HasPrefix(buff.String(), "HTTP/1.1 200 OK\r\n"))
+	assert.True(t, strings.HasSuffix(buff.String(), "origin"))
+
+	// Test: Port outside the allow-list is rejected
+	f = NewForwardProxy()
+	f.AllowedHosts = []string{u.Hostname()}
+	buff.Reset()
+	f.Handle(response.NewResponseWriter(&buff), req)
+	assert.True(t, strings.HasPrefix(buff.String(), "HTTP/1.1 403 Forbidden\r\n"))
+
+	// Test: Host outside the allow-list is rejected
+	f = NewForwardProxy()
+	f.AllowedPorts = []int{port}
+	f.AllowedHosts = []string{"*.example.com"}
+	buff.Reset()
+	f.Handle(response.NewResponseWriter(&buff), req)
+	assert.True(t, strings.HasPrefix(buff.String(), "HTTP/1.1 403 Forbidden\r\n"))
+
+	// Test: Empty host allow-list rejects everything
+	f = NewForwardProxy()
+	f.AllowedPorts = []int{port}
+	buff.Reset()
+	f.Handle(response.NewResponseWriter(&buff), req)
+	assert.True(t, strings.HasPrefix(buff.String(), "HTTP/1.1 403 Forbidden\r\n"))
+
+	// Test: Wildcard matches subdomains at a label boundary only
+	f = NewForwardProxy()
+	f.AllowedHosts = []string{"*.example.com", "*other.com"}
+	assert.True(t, f.allowed("api.example.com", "443"))
+	assert.True(t, f.allowed("a.b.example.com", "443"))
+	assert.False(t, f.allowed("evilexample.com", "443"))
+	assert.False(t, f.allowed("example.com", "443"))
+	assert.False(t, f.allowed("another.com", "443"))
+
+	// Test: Origin-form target is not a proxy request
+	f = NewForwardProxy()
+	buff.Reset()
+	f.Handle(response.NewResponseWriter(&buff), newRequest(t, "GET /coffee HTTP/1.1\r\n\r\n"))
+	assert.True(t, strings.HasPrefix(buff.String(), "HTTP/1.1 400 Bad Request\r\n"))
+}
+
+func TestForwardProxyConnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	// Test: CONNECT replies 200 and splices bytes both ways
+	f := NewForwardProxy()
+	f.AllowedPorts = []int{portNum}
+	f.AllowedHosts = []string{"127.0.0.1"}
+	req := newRequest(t, "CONNECT "+ln.Addr().String()+" HTTP/1.1\r\n\r\n")
+
+	client, serverSide := net.Pipe()
+	defer client.Close()
+	go f.Handle(response.NewConnResponseWriter(serverSide, nil), req)
+
+	r := bufio.NewReader(client)
+	statusLine, err := r.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 200 OK\r\n", statusLine)
+	h, err := textproto.NewReader(r).ReadMIMEHeader()
+	require.NoError(t, err)
+	assert.Empty(t, h.Get("Content-Length"))
+
+	_, err = client.Write([]byte("ping"))
+	require.NoError(t, err)
+	echo := make([]byte, 4)
+	_, err = io.ReadFull(r, echo)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(echo))
+}
+
+func TestSplice(t *testing.T) {
+	// Test: Bytes are copied both ways and both sides closed at the end
+	client, proxyClient := net.Pipe()
+	proxyUpstream, upstream := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		splice(proxyClient, proxyUpstream)
+		close(done)
+	}()
+
+	go client.Write([]byte("ping"))
+	buff := make([]byte, 4)
+	_, err := io.ReadFull(upstream, buff)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buff))
+
+	go upstream.Write([]byte("pong"))
+	_, err = io.ReadFull(client, buff)
+	require.NoError(t, err)
+	assert.Equal(t, "pong", string(buff))
+
+	client.Close()
+	<-done
+	_, err = upstream.Read(buff)
+	require.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,226 @@
+package proxy
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+)
+
+type Strategy int
+
+const (
+	StrategyRoundRobin Strategy = iota
+	StrategyLeastConnections
+	StrategyConsistentHash
+)
+
+const (
+	defaultMaxFails      = 3
+	defaultMaxRetries    = 2
+	defaultEjectDuration = 30 * time.Second
+	ringReplicas         = 100
+)
+
+type Upstream struct {
+	URL          *url.URL
+	activeConns  atomic.Int64
+	failures     atomic.Int64
+	healthy      atomic.Bool
+	ejectedUntil atomic.Int64
+}
+
+func (u *Upstream) available(now time.Time) bool {
+	return u.healthy.Load() && now.UnixNano() >= u.ejectedUntil.Load()
+}
+
+type ringEntry struct {
+	hash     uint32
+	upstream *Upstream
+}
+
+type Pool struct {
+	Strategy      Strategy
+	HashHeader    string
+	MaxFails      int
+	MaxRetries    int
+	EjectDuration time.Duration
+
+	upstreams  []*Upstream
+	ring       []ringEntry
+	next       atomic.Uint64
+	client     *http.Client
+	healthMu   sync.Mutex
+	stopHealth chan struct{}
+}
+
+func NewPool(strategy Strategy, targets ...string) (*Pool, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("Error: upstream pool needs at least one target")
+	}
+
+	p := &Pool{
+		Strategy:      strategy,
+		MaxFails:      defaultMaxFails,
+		MaxRetries:    defaultMaxRetries,
+		EjectDuration: defaultEjectDuration,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+
+	for _, target := range targets {
+		u, err := url.Parse(target)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("Error: invalid upstream URL %q", target)
+		}
+
+		upstream := &Upstream{URL: u}
+		upstream.healthy.Store(true)
+		p.upstreams = append(p.upstreams, upstream)
+
+		for i := range ringReplicas {
+			p.ring = append(p.ring, ringEntry{
+				hash:     hashKey(target + "#" + strconv.Itoa(i)),
+				upstream: upstream,
+			})
+		}
+	}
+	sort.Slice(p.ring, func(i, j int) bool {
+		return p.ring[i].hash < p.ring[j].hash
+	})
+
+	return p, nil
+}
+
+func (p *Pool) Upstreams() []*Upstream {
+	return p.upstreams
+}
+
+func (p *Pool) pick(req *request.Request, tried map[*Upstream]bool) *Upstream {
+	now := time.Now()
+	usable := func(u *Upstream) bool {
+		return !tried[u] && u.available(now)
+	}
+
+	switch p.Strategy {
+	case StrategyLeastConnections:
+		var best *Upstream
+		for _, u := range p.upstreams {
+			if !usable(u) {
+				continue
+			}
+			if best == nil || u.activeConns.Load() < best.activeConns.Load() {
+				best = u
+			}
+		}
+		return best
+	case StrategyConsistentHash:
+		key := req.Headers.Get(p.HashHeader)
+		if key == "" {
+			break
+		}
+
+		h := hashKey(key)
+		start := sort.Search(len(p.ring), func(i int) bool {
+			return p.ring[i].hash >= h
+		})
+		for i := range p.ring {
+			entry := p.ring[(start+i)%len(p.ring)]
+			if usable(entry.upstream) {
+				return entry.upstream
+			}
+		}
+		return nil
+	}
+
+	n := uint64(len(p.upstreams))
+	start := p.next.Add(1) - 1
+	for i := range n {
+		u := p.upstreams[(start+i)%n]
+		if usable(u) {
+			return u
+		}
+	}
+
+	return nil
+}
+
+func (p *Pool) markFailure(u *Upstream) {
+	if u.failures.Add(1) >= int64(p.MaxFails) {
+		u.failures.Store(0)
+		u.ejectedUntil.Store(time.Now().Add(p.EjectDuration).UnixNano())
+	}
+}
+
+func (p *Pool) markSuccess(u *Upstream) {
+	u.failures.Store(0)
+}
+
+func (p *Pool) StartHealthChecks(path string, interval time.Duration) {
+	p.healthMu.Lock()
+	defer p.healthMu.Unlock()
+	if p.stopHealth != nil {
+		return
+	}
+	stop := make(chan struct{})
+	p.stopHealth = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		p.checkAll(path, interval)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				p.checkAll(path, interval)
+			}
+		}
+	}()
+}
+
+func (p *Pool) checkAll(path string, timeout time.Duration) {
+	client := &http.Client{Timeout: timeout}
+	for _, u := range p.upstreams {
+		healthy := false
+		resp, err := client.Get(u.URL.JoinPath(path).String())
+		if err == nil {
+			resp.Body.Close()
+			healthy = resp.StatusCode >= 200 && resp.StatusCode < 400
+		}
+
+		u.healthy.Store(healthy)
+		if healthy {
+			u.failures.Store(0)
+			u.ejectedUntil.Store(0)
+		}
+	}
+}
+
+func (p *Pool) Close() {
+	p.healthMu.Lock()
+	defer p.healthMu.Unlock()
+	if p.stopHealth != nil {
+		close(p.stopHealth)
+		p.stopHealth = nil
+	}
+}
+
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
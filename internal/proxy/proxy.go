@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+)
+
+var hopByHopHeaders = []string{
+	"connection",
+	"keep-alive",
+	"proxy-authenticate",
+	"proxy-authorization",
+	"proxy-connection",
+	"te",
+	"trailer",
+	"transfer-encoding",
+	"upgrade",
+}
+
+func isHopByHop(key string) bool {
+	key = strings.ToLower(key)
+	for _, h := range hopByHopHeaders {
+		if h == key {
+			return true
+		}
+	}
+	return false
+}
+
+// connectionOptions returns the fields named in Connection header values,
+// which only apply to the current hop like the hop-by-hop headers.
+func connectionOptions(values []string) map[string]bool {
+	options := map[string]bool{}
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				options[name] = true
+			}
+		}
+	}
+	return options
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+func (p *Pool) Handle(w *response.Writer, req *request.Request) {
+	attempts := 1
+	if isIdempotent(req.RequestLine.Method) {
+		attempts += p.MaxRetries
+	}
+
+	tried := map[*Upstream]bool{}
+	status := response.StatusServiceUnavailable
+	for range attempts {
+		u := p.pick(req, tried)
+		if u == nil {
+			break
+		}
+		tried[u] = true
+
+		resp, body, err := p.roundTrip(u, req)
+		if err != nil {
+			p.markFailure(u)
+			status = response.StatusBadGateway
+			continue
+		}
+		p.markSuccess(u)
+
+		writeResponse(w, req, resp, body)
+		return
+	}
+
+	response.WriteError(w, status, nil)
+}
+
+func (p *Pool) roundTrip(u *Upstream, req *request.Request) (*http.Response, []byte, error) {
+	u.activeConns.Add(1)
+	defer u.activeConns.Add(-1)
+
+	target := strings.TrimSuffix(u.URL.String(), "/") + req.RequestLine.RequestTarget
+	return forward(p.client, target, req)
+}
+
+func forward(client *http.Client, target string, req *request.Request) (*http.Response, []byte, error) {
+	outReq, err := http.NewRequest(req.RequestLine.Method, target, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	options := connectionOptions(req.Headers.Values("Connection"))
+	for k, values := range req.Headers {
+		if isHopByHop(k) || options[k] || k == "host" || k == "content-length" {
+			continue
+		}
+		for _, v := range values {
+			outReq.Header.Add(k, v)
+		}
+	}
+	if host := req.Headers.Get("Host"); host != "" {
+		outReq.Header.Set("X-Forwarded-Host", host)
+	}
+
+	resp, err := client.Do(outReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp, body, nil
+}
+
+func writeResponse(w *response.Writer, req *request.Request, resp *http.Response, body []byte) {
+	w.WriteStatusLine(response.StatusCode(resp.StatusCode))
+
+	h := headers.NewHeaders()
+	options := connectionOptions(resp.Header.Values("Connection"))
+	for k, values := range resp.Header {
+		if isHopByHop(k) || options[strings.ToLower(k)] || strings.EqualFold(k, "Content-Length") {
+			continue
+		}
+		for _, v := range values {
+			h.Add(k, v)
+		}
+	}
+	contentLength := strconv.Itoa(len(body))
+	if req.RequestLine.Method == "HEAD" && resp.ContentLength >= 0 {
+		contentLength = strconv.FormatInt(resp.ContentLength, 10)
+	}
+	h.Replace("Content-Length", contentLength)
+	h.Replace("Connection", "close")
+	w.WriteHeaders(h)
+
+	w.WriteBody(body)
+}
@@ -0,0 +1,137 @@
+package proxy
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBackend(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Write([]byte(name))
+	}))
+}
+
+func newRequest(t *testing.T, raw string) *request.Request {
+	req, err := request.RequestFromReader(strings.NewReader(raw))
+	require.NoError(t, err)
+	return req
+}
+
+func proxyOnce(p *Pool, req *request.Request) string {
+	var buff bytes.Buffer
+	p.Handle(response.NewResponseWriter(&buff), req)
+	return buff.String()
+}
+
+func TestPoolStrategies(t *testing.T) {
+	a := newBackend("backend-a")
+	defer a.Close()
+	b := newBackend("backend-b")
+	defer b.Close()
+
+	// Test: Round robin alternates between upstreams
+	p, err := NewPool(StrategyRoundRobin, a.URL, b.URL)
+	require.NoError(t, err)
+	req := newRequest(t, "GET /coffee HTTP/1.1\r\nHost: localhost:42069\r\n\r\n")
+	first := proxyOnce(p, req)
+	second := proxyOnce(p, req)
+	assert.True(t, strings.HasPrefix(first, "HTTP/1.1 200 OK\r\n"))
+	assert.True(t, strings.HasSuffix(first, "backend-a"))
+	assert.True(t, strings.HasSuffix(second, "backend-b"))
+
+	// Test: Least connections prefers the idle upstream
+	p, err = NewPool(StrategyLeastConnections, a.URL, b.URL)
+	require.NoError(t, err)
+	p.upstreams[0].activeConns.Add(5)
+	assert.True(t, strings.HasSuffix(proxyOnce(p, req), "backend-b"))
+
+	// Test: Consistent hash keeps a key on the same upstream
+	p, err = NewPool(StrategyConsistentHash, a.URL, b.URL)
+	require.NoError(t, err)
+	p.HashHeader = "X-User"
+	req = newRequest(t, "GET / HTTP/1.1\r\nX-User: alice\r\n\r\n")
+	first = proxyOnce(p, req)
+	name := first[strings.LastIndex(first, "\r\n")+2:]
+	for range 5 {
+		assert.True(t, strings.HasSuffix(proxyOnce(p, req), name))
+	}
+
+	// Test: Invalid upstream URL
+	_, err = NewPool(StrategyRoundRobin, "localhost:8080")
+	require.Error(t, err)
+}
+
+func TestPoolFailures(t *testing.T) {
+	good := newBackend("good")
+	defer good.Close()
+	bad := newBackend("bad")
+	bad.Close()
+
+	// Test: Idempotent request is retried on another upstream
+	p, err := NewPool(StrategyRoundRobin, bad.URL, good.URL)
+	require.NoError(t, err)
+	req := newRequest(t, "GET / HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasSuffix(proxyOnce(p, req), "good"))
+
+	// Test: Non-idempotent request is not retried
+	p, err = NewPool(StrategyRoundRobin, bad.URL, good.URL)
+	require.NoError(t, err)
+	req = newRequest(t, "POST / HTTP/1.1\r\nContent-Length: 2\r\n\r\nhi")
+	assert.True(t, strings.HasPrefix(proxyOnce(p, req), "HTTP/1.1 502 Bad Gateway\r\n"))
+
+	// Test: Upstream is ejected after consecutive failures
+	p, err = NewPool(StrategyRoundRobin, bad.URL)
+	require.NoError(t, err)
+	p.MaxFails = 2
+	req = newRequest(t, "GET / HTTP/1.1\r\n\r\n")
+	proxyOnce(p, req)
+	proxyOnce(p, req)
+	assert.False(t, p.upstreams[0].available(time.Now()))
+	assert.True(t, strings.HasPrefix(proxyOnce(p, req), "HTTP/1.1 503 Service Unavailable\r\n"))
+
+	// Test: Active health check marks upstreams
+	p, err = NewPool(StrategyRoundRobin, bad.URL, good.URL)
+	require.NoError(t, err)
+	p.checkAll("/health", time.Second)
+	assert.False(t, p.upstreams[0].healthy.Load())
+	assert.True(t, p.upstreams[1].healthy.Load())
+}
+
+func TestProxyHeaders(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Set-Cookie", "a=1; Expires=Wed, 21 Oct 2026 07:28:00 GMT")
+		w.Header().Add("Set-Cookie", "b=2")
+		w.Header().Set("Connection", "X-Backend-Hop")
+		w.Header().Set("X-Backend-Hop", "1")
+		w.Header().Set("X-Saw-Client-Hop", r.Header.Get("X-Client-Hop"))
+		w.Header().Set("X-Saw-Kept", r.Header.Get("X-Kept"))
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	// Test: Set-Cookie lines are relayed separately
+	p, err := NewPool(StrategyRoundRobin, backend.URL)
+	require.NoError(t, err)
+	out := proxyOnce(p, newRequest(t, "GET / HTTP/1.1\r\nHost: localhost:42069\r\n\r\n"))
+	assert.Contains(t, out, "set-cookie: a=1; Expires=Wed, 21 Oct 2026 07:28:00 GMT\r\n")
+	assert.Contains(t, out, "set-cookie: b=2\r\n")
+
+	// Test: Fields named in Connection are not forwarded either way
+	out = proxyOnce(p, newRequest(t, "GET / HTTP/1.1\r\nHost: localhost:42069\r\nConnection: keep-alive, X-Client-Hop\r\nX-Client-Hop: 1\r\nX-Kept: 1\r\n\r\n"))
+	assert.Contains(t, out, "x-saw-client-hop: \r\n")
+	assert.Contains(t, out, "x-saw-kept: 1\r\n")
+	assert.NotContains(t, out, "x-backend-hop")
+}
@@ -0,0 +1,253 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// v1MaxLength is the longest v1 header allowed, CRLF included.
+const v1MaxLength = 107
+
+var (
+	ErrInvalidHeader = errors.New("Error: invalid PROXY protocol header")
+	ErrMissingHeader = errors.New("Error: missing PROXY protocol header")
+)
+
+type Command byte
+
+const (
+	CommandLocal Command = 0x0
+	CommandProxy Command = 0x1
+)
+
+// TLV types defined by the v2 specification.
+const (
+	TLVTypeALPN      byte = 0x01
+	TLVTypeAuthority byte = 0x02
+	TLVTypeCRC32C    byte = 0x03
+	TLVTypeNoop      byte = 0x04
+	TLVTypeUniqueID  byte = 0x05
+	TLVTypeSSL       byte = 0x20
+	TLVTypeNetNS     byte = 0x30
+)
+
+type TLV struct {
+	Type  byte
+	Value []byte
+}
+
+// Header is a parsed PROXY protocol header. Source and Destination are nil
+// for LOCAL commands and unknown address families, in which case the
+// connection's own addresses apply.
+type Header struct {
+	Version     int
+	Command     Command
+	Source      net.Addr
+	Destination net.Addr
+	TLVs        []TLV
+}
+
+// TLV returns the value of the first TLV of the given type.
+func (h *Header) TLV(typ byte) ([]byte, bool) {
+	for _, tlv := range h.TLVs {
+		if tlv.Type == typ {
+			return tlv.Value, true
+		}
+	}
+	return nil, false
+}
+
+// ReadHeader reads a v1 or v2 header from r. It returns nil without consuming
+// anything when r doesn't start with one, and the read error when r ends or
+// fails before that can be told.
+func ReadHeader(r *bufio.Reader) (*Header, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch first[0] {
+	case v1Prefix[0]:
+		if ok, err := hasPrefix(r, v1Prefix); ok {
+			return readV1(r)
+		} else if err != nil {
+			return nil, err
+		}
+	case v2Signature[0]:
+		if ok, err := hasPrefix(r, v2Signature); ok {
+			return readV2(r)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// hasPrefix reports whether r starts with prefix, only returning the read
+// error while the bytes seen so far still match it.
+func hasPrefix(r *bufio.Reader, prefix []byte) (bool, error) {
+	start, err := r.Peek(len(prefix))
+	if !bytes.HasPrefix(prefix, start) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func readV1(r *bufio.Reader) (*Header, error) {
+	var line []byte
+	for len(line) < v1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("%w: v1 header too long", ErrInvalidHeader)
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	header := &Header{Version: 1, Command: CommandProxy}
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return header, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidHeader, line)
+	}
+
+	source, err := parseV1Addr(fields[1], fields[2], fields[4])
+	if err != nil {
+		return nil, err
+	}
+	destination, err := parseV1Addr(fields[1], fields[3], fields[5])
+	if err != nil {
+		return nil, err
+	}
+	header.Source = source
+	header.Destination = destination
+	return header, nil
+}
+
+func parseV1Addr(family, ip, port string) (*net.TCPAddr, error) {
+	// The family follows the textual form, so an IPv4-mapped IPv6 address
+	// such as ::ffff:192.0.2.1 is valid under TCP6.
+	parsed := net.ParseIP(ip)
+	if parsed == nil || (family == "TCP6") != strings.Contains(ip, ":") {
+		return nil, fmt.Errorf("%w: address %q", ErrInvalidHeader, ip)
+	}
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || (len(port) > 1 && port[0] == '0') {
+		return nil, fmt.Errorf("%w: port %q", ErrInvalidHeader, port)
+	}
+	return &net.TCPAddr{IP: parsed, Port: int(n)}, nil
+}
+
+func readV2(r *bufio.Reader) (*Header, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, err
+	}
+
+	if fixed[12]>>4 != 2 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidHeader, fixed[12]>>4)
+	}
+	command := Command(fixed[12] & 0x0f)
+	if command != CommandLocal && command != CommandProxy {
+		return nil, fmt.Errorf("%w: unsupported command %d", ErrInvalidHeader, command)
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(fixed[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	header := &Header{Version: 2, Command: command}
+	family, transport := fixed[13]>>4, fixed[13]&0x0f
+
+	var addrLen int
+	switch family {
+	case 0x1:
+		addrLen = 12
+	case 0x2:
+		addrLen = 36
+	case 0x3:
+		addrLen = 216
+	}
+	if len(payload) < addrLen {
+		return nil, fmt.Errorf("%w: address block too short", ErrInvalidHeader)
+	}
+
+	if command == CommandProxy {
+		header.Source, header.Destination = v2Addrs(family, transport, payload[:addrLen])
+	}
+
+	tlvs, err := parseTLVs(payload[addrLen:])
+	if err != nil {
+		return nil, err
+	}
+	header.TLVs = tlvs
+	return header, nil
+}
+
+func v2Addrs(family, transport byte, block []byte) (net.Addr, net.Addr) {
+	switch family {
+	case 0x1, 0x2:
+		size := 4
+		if family == 0x2 {
+			size = 16
+		}
+		srcIP := net.IP(bytes.Clone(block[:size]))
+		dstIP := net.IP(bytes.Clone(block[size : 2*size]))
+		srcPort := int(binary.BigEndian.Uint16(block[2*size:]))
+		dstPort := int(binary.BigEndian.Uint16(block[2*size+2:]))
+		if transport == 0x2 {
+			return &net.UDPAddr{IP: srcIP, Port: srcPort}, &net.UDPAddr{IP: dstIP, Port: dstPort}
+		}
+		return &net.TCPAddr{IP: srcIP, Port: srcPort}, &net.TCPAddr{IP: dstIP, Port: dstPort}
+	case 0x3:
+		network := "unix"
+		if transport == 0x2 {
+			network = "unixgram"
+		}
+		return &net.UnixAddr{Name: unixPath(block[:108]), Net: network}, &net.UnixAddr{Name: unixPath(block[108:]), Net: network}
+	}
+	return nil, nil
+}
+
+func unixPath(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+func parseTLVs(data []byte) ([]TLV, error) {
+	var tlvs []TLV
+	for len(data) > 0 {
+		if len(data) < 3 {
+			return nil, fmt.Errorf("%w: truncated TLV", ErrInvalidHeader)
+		}
+		length := int(binary.BigEndian.Uint16(data[1:3]))
+		if len(data) < 3+length {
+			return nil, fmt.Errorf("%w: truncated TLV", ErrInvalidHeader)
+		}
+		tlvs = append(tlvs, TLV{Type: data[0], Value: bytes.Clone(data[3 : 3+length])})
+		data = data[3+length:]
+	}
+	return tlvs, nil
+}
@@ -0,0 +1,137 @@
+package proxyproto
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultHeaderTimeout = 5 * time.Second
+
+// Listener accepts connections that may start with a PROXY protocol header.
+// Connections from the trusted networks must send one, other connections are
+// passed through untouched so a forged header fails as a malformed request.
+type Listener struct {
+	net.Listener
+	Trusted       []netip.Prefix
+	HeaderTimeout time.Duration
+}
+
+// NewListener wraps ln, trusting headers from the given CIDRs.
+func NewListener(ln net.Listener, trustedCIDRs ...string) (*Listener, error) {
+	l := &Listener{
+		Listener:      ln,
+		HeaderTimeout: defaultHeaderTimeout,
+	}
+	for _, cidr := range trustedCIDRs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("Error: invalid trusted CIDR %q: %w", cidr, err)
+		}
+		l.Trusted = append(l.Trusted, prefix.Masked())
+	}
+	return l, nil
+}
+
+// Accept returns the next connection. The header is read on first use of the
+// connection so a slow client can't hold up the accept loop.
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !l.trusts(conn.RemoteAddr()) {
+		return conn, nil
+	}
+
+	return &Conn{
+		Conn:    conn,
+		reader:  bufio.NewReader(conn),
+		timeout: l.HeaderTimeout,
+	}, nil
+}
+
+func (l *Listener) trusts(addr net.Addr) bool {
+	addrPort, err := netip.ParseAddrPort(addr.String())
+	if err != nil {
+		return false
+	}
+	ip := addrPort.Addr().Unmap()
+	for _, prefix := range l.Trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Conn is a connection from a trusted proxy. Its addresses are those sent in
+// the PROXY header. A missing or invalid header, or a read error or timeout
+// before it, closes the connection and fails every read.
+type Conn struct {
+	net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+
+	once   sync.Once
+	header *Header
+	err    error
+}
+
+func (c *Conn) readHeader() {
+	c.once.Do(func() {
+		if c.timeout > 0 {
+			c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+			defer c.Conn.SetReadDeadline(time.Time{})
+		}
+		c.header, c.err = ReadHeader(c.reader)
+		if c.header == nil && c.err == nil {
+			c.err = ErrMissingHeader
+		}
+		if c.err != nil {
+			c.Conn.Close()
+		}
+	})
+}
+
+// Header returns the PROXY header sent on the connection.
+func (c *Conn) Header() (*Header, error) {
+	c.readHeader()
+	return c.header, c.err
+}
+
+func (c *Conn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(p)
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	c.readHeader()
+	if c.header != nil && c.header.Source != nil {
+		return c.header.Source
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	c.readHeader()
+	if c.header != nil && c.header.Destination != nil {
+		return c.header.Destination
+	}
+	return c.Conn.LocalAddr()
+}
@@ -0,0 +1,207 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/Quak1/learn-http-go/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func read(t *testing.T, data []byte) (*Header, string, error) {
+	r := bufio.NewReader(bytes.NewReader(data))
+	h, err := ReadHeader(r)
+	rest, _ := io.ReadAll(r)
+	return h, string(rest), err
+}
+
+func v2(command, family byte, addrs []byte, tlvs ...TLV) []byte {
+	var payload []byte
+	payload = append(payload, addrs...)
+	for _, tlv := range tlvs {
+		payload = append(payload, tlv.Type)
+		payload = binary.BigEndian.AppendUint16(payload, uint16(len(tlv.Value)))
+		payload = append(payload, tlv.Value...)
+	}
+
+	header := append([]byte{}, v2Signature...)
+	header = append(header, 0x20|command, family)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	return append(header, payload...)
+}
+
+func TestReadHeaderV1(t *testing.T) {
+	// Test: TCP4
+	h, rest, err := read(t, []byte("PROXY TCP4 203.0.113.7 192.0.2.1 56324 443\r\nGET / HTTP/1.1\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, h.Version)
+	assert.Equal(t, "203.0.113.7:56324", h.Source.String())
+	assert.Equal(t, "192.0.2.1:443", h.Destination.String())
+	assert.Equal(t, "GET / HTTP/1.1\r\n", rest)
+
+	// Test: TCP6
+	h, _, err = read(t, []byte("PROXY TCP6 2001:db8::1 2001:db8::2 4000 80\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "[2001:db8::1]:4000", h.Source.String())
+
+	// Test: TCP6 with IPv4-mapped addresses
+	h, _, err = read(t, []byte("PROXY TCP6 ::ffff:192.0.2.1 ::ffff:192.0.2.2 4000 80\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "192.0.2.1:4000", h.Source.String())
+
+	// Test: UNKNOWN keeps the connection addresses
+	h, _, err = read(t, []byte("PROXY UNKNOWN ffff:f...f:ffff ffff:f...f:ffff 65535 65535\r\n"))
+	require.NoError(t, err)
+	assert.Nil(t, h.Source)
+
+	// Test: No header
+	h, rest, err = read(t, []byte("GET / HTTP/1.1\r\n"))
+	require.NoError(t, err)
+	assert.Nil(t, h)
+	assert.Equal(t, "GET / HTTP/1.1\r\n", rest)
+	h, rest, err = read(t, []byte("PUT"))
+	require.NoError(t, err)
+	assert.Nil(t, h)
+	assert.Equal(t, "PUT", rest)
+
+	// Test: Input ending before a header can be told apart
+	_, _, err = read(t, nil)
+	require.ErrorIs(t, err, io.EOF)
+	_, _, err = read(t, []byte("PRO"))
+	require.ErrorIs(t, err, io.EOF)
+
+	// Test: Invalid headers
+	for _, raw := range []string{
+		"PROXY TCP4 203.0.113.7 192.0.2.1 56324\r\n",
+		"PROXY TCP4 2001:db8::1 192.0.2.1 56324 443\r\n",
+		"PROXY TCP4 ::ffff:203.0.113.7 192.0.2.1 56324 443\r\n",
+		"PROXY TCP6 203.0.113.7 2001:db8::2 56324 443\r\n",
+		"PROXY TCP4 203.0.113.7 192.0.2.1 056324 443\r\n",
+		"PROXY TCP4 203.0.113.7 192.0.2.1 99999 443\r\n",
+		"PROXY TCP4 " + strings.Repeat("1", 120) + "\r\n",
+	} {
+		_, _, err = read(t, []byte(raw))
+		require.ErrorIs(t, err, ErrInvalidHeader, raw)
+	}
+}
+
+func TestReadHeaderV2(t *testing.T) {
+	// Test: TCP over IPv4 with TLVs
+	addrs := []byte{203, 0, 113, 7, 192, 0, 2, 1, 0xdc, 0x04, 0x01, 0xbb}
+	raw := v2(0x1, 0x11, addrs, TLV{Type: TLVTypeAuthority, Value: []byte("example.com")}, TLV{Type: TLVTypeUniqueID, Value: []byte{1, 2}})
+	h, rest, err := read(t, append(raw, "GET /"...))
+	require.NoError(t, err)
+	assert.Equal(t, 2, h.Version)
+	assert.Equal(t, CommandProxy, h.Command)
+	assert.Equal(t, "203.0.113.7:56324", h.Source.String())
+	assert.Equal(t, "192.0.2.1:443", h.Destination.String())
+	authority, ok := h.TLV(TLVTypeAuthority)
+	require.True(t, ok)
+	assert.Equal(t, "example.com", string(authority))
+	assert.Len(t, h.TLVs, 2)
+	assert.Equal(t, "GET /", rest)
+
+	// Test: TCP over IPv6
+	addrs = make([]byte, 36)
+	copy(addrs, net.ParseIP("2001:db8::1"))
+	copy(addrs[16:], net.ParseIP("2001:db8::2"))
+	binary.BigEndian.PutUint16(addrs[32:], 4000)
+	binary.BigEndian.PutUint16(addrs[34:], 80)
+	h, _, err = read(t, v2(0x1, 0x21, addrs))
+	require.NoError(t, err)
+	assert.Equal(t, "[2001:db8::1]:4000", h.Source.String())
+
+	// Test: Unix sockets
+	addrs = make([]byte, 216)
+	copy(addrs, "/run/client.sock")
+	copy(addrs[108:], "/run/lb.sock")
+	h, _, err = read(t, v2(0x1, 0x31, addrs))
+	require.NoError(t, err)
+	assert.Equal(t, "/run/client.sock", h.Source.String())
+
+	// Test: LOCAL command has no addresses
+	h, _, err = read(t, v2(0x0, 0x00, nil))
+	require.NoError(t, err)
+	assert.Equal(t, CommandLocal, h.Command)
+	assert.Nil(t, h.Source)
+
+	// Test: Truncated TLV
+	raw = v2(0x1, 0x11, []byte{203, 0, 113, 7, 192, 0, 2, 1, 0, 1, 0, 2, TLVTypeNoop, 0, 9})
+	_, _, err = read(t, raw)
+	require.ErrorIs(t, err, ErrInvalidHeader)
+
+	// Test: Unsupported command
+	_, _, err = read(t, v2(0x7, 0x11, make([]byte, 12)))
+	require.ErrorIs(t, err, ErrInvalidHeader)
+}
+
+func TestListener(t *testing.T) {
+	_, err := NewListener(nil, "not-a-cidr")
+	require.Error(t, err)
+
+	serve := func(trusted string) *server.Server {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		pl, err := NewListener(ln, trusted)
+		require.NoError(t, err)
+
+		return server.ServeListener(pl, func(w *response.Writer, req *request.Request) {
+			w.WriteStatusLine(response.StatusOK)
+			w.WriteHeaders(response.GetDefaultHeaders(len(req.RemoteAddr)))
+			w.WriteBody([]byte(req.RemoteAddr))
+		})
+	}
+	send := func(s *server.Server, raw string) string {
+		conn, err := net.Dial("tcp", s.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = conn.Write([]byte(raw))
+		require.NoError(t, err)
+		out, err := io.ReadAll(conn)
+		require.NoError(t, err)
+		return string(out)
+	}
+
+	// Test: Trusted proxy reports the client address
+	s := serve("127.0.0.0/8")
+	defer s.Close()
+	out := send(s, "PROXY TCP4 203.0.113.7 192.0.2.1 56324 443\r\nGET / HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasSuffix(out, "\r\n\r\n203.0.113.7:56324"))
+
+	// Test: Trusted proxy without a header is rejected
+	out = send(s, "GET / HTTP/1.1\r\n\r\n")
+	assert.Empty(t, out)
+
+	// Test: Trusted proxy timing out before a header is rejected
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	pl, err := NewListener(ln, "127.0.0.0/8")
+	require.NoError(t, err)
+	defer pl.Close()
+	pl.HeaderTimeout = 50 * time.Millisecond
+	client, err := net.Dial("tcp", pl.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	conn, err := pl.Accept()
+	require.NoError(t, err)
+	_, err = conn.Read(make([]byte, 1))
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	_, err = client.Read(make([]byte, 1))
+	require.ErrorIs(t, err, io.EOF)
+
+	// Test: Headers from untrusted sources are not honored
+	s2 := serve("10.0.0.0/8")
+	defer s2.Close()
+	out = send(s2, "PROXY TCP4 203.0.113.7 192.0.2.1 56324 443\r\nGET / HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 400 Bad Request\r\n"))
+}
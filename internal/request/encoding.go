@@ -0,0 +1,78 @@
+package request
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnsupportedEncoding = errors.New("Error: unsupported Content-Encoding")
+	ErrBodyTooLarge        = errors.New("Error: decoded body exceeds size limit")
+)
+
+// DecodeBody undoes the codings listed in Content-Encoding, last applied
+// first, and replaces Body with the result. maxSize bounds the size of every
+// decoding step so a small compressed body can't expand without limit.
+func (r *Request) DecodeBody(maxSize int64) error {
+	contentEncoding := r.Headers.Get("Content-Encoding")
+	if contentEncoding == "" {
+		return nil
+	}
+
+	var codings []string
+	for _, coding := range strings.Split(contentEncoding, ",") {
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" || coding == "identity" {
+			continue
+		}
+		if coding != "gzip" && coding != "x-gzip" && coding != "deflate" {
+			return fmt.Errorf("%w: %s", ErrUnsupportedEncoding, coding)
+		}
+		codings = append(codings, coding)
+	}
+
+	body := r.Body
+	for i := len(codings) - 1; i >= 0; i-- {
+		decoded, err := decode(codings[i], body, maxSize)
+		if err != nil {
+			return err
+		}
+		body = decoded
+	}
+
+	r.Body = body
+	r.Headers.Delete("Content-Encoding")
+	r.Headers.Replace("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+func decode(coding string, data []byte, maxSize int64) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+	switch coding {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(data))
+	case "deflate":
+		reader, err = zlib.NewReader(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error: invalid %s body: %w", coding, err)
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("Error: invalid %s body: %w", coding, err)
+	}
+	if int64(len(decoded)) > maxSize {
+		return nil, ErrBodyTooLarge
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,83 @@
+package request
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	var buff bytes.Buffer
+	w := gzip.NewWriter(&buff)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buff.Bytes()
+}
+
+func deflateBytes(t *testing.T, data []byte) []byte {
+	var buff bytes.Buffer
+	w := zlib.NewWriter(&buff)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buff.Bytes()
+}
+
+func encodedRequest(t *testing.T, encoding string, body []byte) *Request {
+	reader := &chunkReader{
+		data: "POST /upload HTTP/1.1\r\n" +
+			"Content-Encoding: " + encoding + "\r\n" +
+			"Content-Length: " + strconv.Itoa(len(body)) + "\r\n" +
+			"\r\n" +
+			string(body),
+		numBytesPerRead: 100,
+	}
+	r, err := RequestFromReader(reader)
+	require.NoError(t, err)
+	return r
+}
+
+func TestDecodeBody(t *testing.T) {
+	payload := []byte(`{"hello": "world"}`)
+
+	// Test: gzip body
+	r := encodedRequest(t, "gzip", gzipBytes(t, payload))
+	require.NoError(t, r.DecodeBody(1024))
+	assert.Equal(t, payload, r.Body)
+	assert.Equal(t, "", r.Headers.Get("Content-Encoding"))
+	assert.Equal(t, strconv.Itoa(len(payload)), r.Headers.Get("Content-Length"))
+
+	// Test: Stacked codings are decoded in reverse order
+	r = encodedRequest(t, "deflate, gzip", gzipBytes(t, deflateBytes(t, payload)))
+	require.NoError(t, r.DecodeBody(1024))
+	assert.Equal(t, payload, r.Body)
+
+	// Test: No Content-Encoding leaves the body untouched
+	reader := &chunkReader{
+		data:            "POST /upload HTTP/1.1\r\nContent-Length: 2\r\n\r\nhi",
+		numBytesPerRead: 100,
+	}
+	r, err := RequestFromReader(reader)
+	require.NoError(t, err)
+	require.NoError(t, r.DecodeBody(1024))
+	assert.Equal(t, "hi", string(r.Body))
+
+	// Test: Unsupported coding
+	r = encodedRequest(t, "br", payload)
+	require.ErrorIs(t, r.DecodeBody(1024), ErrUnsupportedEncoding)
+
+	// Test: Decoded body over the limit
+	r = encodedRequest(t, "gzip", gzipBytes(t, []byte(strings.Repeat("a", 4096))))
+	require.ErrorIs(t, r.DecodeBody(1024), ErrBodyTooLarge)
+
+	// Test: Corrupt body
+	r = encodedRequest(t, "gzip", []byte("not gzip"))
+	require.Error(t, r.DecodeBody(1024))
+}
@@ -0,0 +1,47 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+)
+
+var ErrNotJSON = errors.New("Error: Content-Type is not application/json")
+
+// DecodeJSON decodes a JSON body into v. The body must be labelled
+// application/json (or a +json type), hold a single value no larger than
+// maxSize bytes and use only fields known to v. The parser has already read
+// the whole body by then, so maxSize limits what is decoded and is not a bound
+// on the memory a request can take.
+func (r *Request) DecodeJSON(v any, maxSize int64) error {
+	if !isJSON(r.Headers.Get("Content-Type")) {
+		return ErrNotJSON
+	}
+	if int64(len(r.Body)) > maxSize {
+		return ErrBodyTooLarge
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(r.Body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("Error: invalid JSON body: %w", err)
+	}
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("Error: invalid JSON body: unexpected data after value")
+	}
+
+	return nil
+}
+
+func isJSON(contentType string) bool {
+	mediaType, err := headers.ParseMediaType(contentType)
+	if err != nil || mediaType.Type != "application" {
+		return false
+	}
+	return mediaType.Subtype == "json" || strings.HasSuffix(mediaType.Subtype, "+json")
+}
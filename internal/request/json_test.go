@@ -0,0 +1,60 @@
+package request
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type payload struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func jsonRequest(contentType, body string) *Request {
+	r := &Request{Headers: headers.NewHeaders(), Body: []byte(body)}
+	if contentType != "" {
+		r.Headers.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func TestDecodeJSON(t *testing.T) {
+	// Test: Valid body
+	var p payload
+	err := jsonRequest("application/json; charset=utf-8", `{"name":"a","count":2}`).DecodeJSON(&p, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, payload{Name: "a", Count: 2}, p)
+
+	// Test: +json media type
+	err = jsonRequest("application/merge-patch+json", `{"name":"b"}`).DecodeJSON(&p, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "b", p.Name)
+
+	// Test: Wrong Content-Type
+	err = jsonRequest("text/plain", `{"name":"a"}`).DecodeJSON(&p, 1024)
+	assert.True(t, errors.Is(err, ErrNotJSON))
+
+	// Test: Missing Content-Type
+	err = jsonRequest("", `{"name":"a"}`).DecodeJSON(&p, 1024)
+	assert.True(t, errors.Is(err, ErrNotJSON))
+
+	// Test: Body too large
+	err = jsonRequest("application/json", `{"name":"abcdefgh"}`).DecodeJSON(&p, 8)
+	assert.True(t, errors.Is(err, ErrBodyTooLarge))
+
+	// Test: Unknown field
+	err = jsonRequest("application/json", `{"name":"a","extra":1}`).DecodeJSON(&p, 1024)
+	require.Error(t, err)
+
+	// Test: Malformed body
+	err = jsonRequest("application/json", `{"name":`).DecodeJSON(&p, 1024)
+	require.Error(t, err)
+
+	// Test: Trailing data
+	err = jsonRequest("application/json", `{"name":"a"} {}`).DecodeJSON(&p, 1024)
+	require.Error(t, err)
+}
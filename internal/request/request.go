@@ -2,9 +2,12 @@ package request
 
 import (
 	"bytes"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -13,6 +16,12 @@ import (
 
 const bufferSize = 8
 
+// ErrMalformedRequest wraps errors caused by a request that doesn't follow the
+// HTTP/1.1 message syntax, as opposed to a failing connection.
+var ErrMalformedRequest = errors.New("Error: malformed request")
+
+var ErrIncompleteRequest = errors.New("Error: incomplete request")
+
 type parserState int
 
 const (
@@ -26,7 +35,11 @@ type Request struct {
 	RequestLine RequestLine
 	Headers     headers.Headers
 	Body        []byte
+	RemoteAddr  string
+	Pattern     string
+	TLS         *tls.ConnectionState
 	state       parserState
+	buffered    []byte
 }
 
 type RequestLine struct {
@@ -53,7 +66,7 @@ func RequestFromReader(reader io.Reader) (*Request, error) {
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				if request.state != stateDone {
-					return nil, fmt.Errorf("Error: incomplete request")
+					return nil, ErrIncompleteRequest
 				}
 				break
 			}
@@ -64,16 +77,26 @@ func RequestFromReader(reader io.Reader) (*Request, error) {
 
 		n, err = request.parse(buff[:readToIndex])
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %w", ErrMalformedRequest, err)
 		}
 
 		copy(buff, buff[n:])
 		readToIndex -= n
 	}
 
+	if readToIndex > 0 {
+		request.buffered = append([]byte(nil), buff[:readToIndex]...)
+	}
+
 	return request, nil
 }
 
+// Buffered returns the bytes read from the connection past the end of the
+// request, such as the start of a pipelined request or an upgraded protocol.
+func (r *Request) Buffered() []byte {
+	return r.buffered
+}
+
 func (r *Request) parse(data []byte) (int, error) {
 	totalBytesParsed := 0
 	for r.state != stateDone {
@@ -118,24 +141,24 @@ func (r *Request) parseSingle(data []byte) (int, error) {
 		contentLengthStr := r.Headers.Get("Content-Length")
 		if contentLengthStr == "" {
 			r.state = stateDone
-			return len(data), nil
+			return 0, nil
 		}
 
 		contentLength, err := strconv.Atoi(contentLengthStr)
 		if err != nil {
 			return 0, fmt.Errorf("Error: invalid Content-Length value, not a number")
 		}
-		r.Body = append(r.Body, data...)
-
-		bodyLen := len(r.Body)
-		if bodyLen > contentLength {
-			return 0, fmt.Errorf("Error: available body data larger than Content-Length value")
+		if contentLength < 0 {
+			return 0, fmt.Errorf("Error: invalid Content-Length value, negative number")
 		}
-		if bodyLen == contentLength {
+
+		n := min(contentLength-len(r.Body), len(data))
+		r.Body = append(r.Body, data[:n]...)
+		if len(r.Body) == contentLength {
 			r.state = stateDone
 		}
 
-		return len(data), nil
+		return n, nil
 	case stateDone:
 		return 0, fmt.Errorf("Error: trying to read data in a done state")
 	default:
@@ -161,6 +184,10 @@ func parseRequestLine(data []byte) (*RequestLine, int, error) {
 		}
 	}
 
+	if err := validateRequestTarget(method, parts[1]); err != nil {
+		return nil, 0, err
+	}
+
 	httpParts := strings.Split(parts[2], "/")
 	if len(httpParts) != 2 {
 		return nil, 0, fmt.Errorf("Request line: invalid HTTP-version")
@@ -180,3 +207,22 @@ func parseRequestLine(data []byte) (*RequestLine, int, error) {
 
 	return requestLine, idx + 2, nil
 }
+
+func validateRequestTarget(method, target string) error {
+	switch {
+	case method == "CONNECT":
+		host, port, err := net.SplitHostPort(target)
+		if err != nil || host == "" || port == "" {
+			return fmt.Errorf("Request line: invalid authority-form target")
+		}
+	case strings.HasPrefix(target, "/"):
+	case target == "*" && method == "OPTIONS":
+	default:
+		u, err := url.Parse(target)
+		if err != nil || !u.IsAbs() || u.Host == "" {
+			return fmt.Errorf("Request line: invalid request target")
+		}
+	}
+
+	return nil
+}
@@ -91,6 +91,43 @@ func TestRequestLineParse(t *testing.T) {
 	assert.Equal(t, "/coffee", r.RequestLine.RequestTarget)
 	assert.Equal(t, "1.1", r.RequestLine.HttpVersion)
 
+	// Test: Good CONNECT Request line with authority-form target
+	reader = &chunkReader{
+		data:            "CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\n",
+		numBytesPerRead: 3,
+	}
+	r, err = RequestFromReader(reader)
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, "CONNECT", r.RequestLine.Method)
+	assert.Equal(t, "example.com:443", r.RequestLine.RequestTarget)
+
+	// Test: Good GET Request line with absolute-form target
+	reader = &chunkReader{
+		data:            "GET http://example.com/coffee?q=1 HTTP/1.1\r\nHost: example.com\r\n\r\n",
+		numBytesPerRead: 3,
+	}
+	r, err = RequestFromReader(reader)
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, "http://example.com/coffee?q=1", r.RequestLine.RequestTarget)
+
+	// Test: Invalid CONNECT target without port
+	reader = &chunkReader{
+		data:            "CONNECT example.com HTTP/1.1\r\nHost: example.com\r\n\r\n",
+		numBytesPerRead: 3,
+	}
+	_, err = RequestFromReader(reader)
+	require.Error(t, err)
+
+	// Test: Invalid request target
+	reader = &chunkReader{
+		data:            "GET coffee HTTP/1.1\r\nHost: localhost:42069\r\n\r\n",
+		numBytesPerRead: 3,
+	}
+	_, err = RequestFromReader(reader)
+	require.Error(t, err)
+
 	// Test: Invalid number of parts in request line
 	reader = &chunkReader{
 		data:            "/coffee HTTP/1.1\r\nHost: localhost:42069\r\nUser-Agent: curl/7.81.0\r\nAccept: */*\r\n\r\n",
@@ -141,9 +178,9 @@ func TestHeadersParse(t *testing.T) {
 	r, err := RequestFromReader(reader)
 	require.NoError(t, err)
 	require.NotNil(t, r)
-	assert.Equal(t, "localhost:42069", r.Headers["host"])
-	assert.Equal(t, "curl/7.81.0", r.Headers["user-agent"])
-	assert.Equal(t, "*/*", r.Headers["accept"])
+	assert.Equal(t, "localhost:42069", r.Headers.Get("host"))
+	assert.Equal(t, "curl/7.81.0", r.Headers.Get("user-agent"))
+	assert.Equal(t, "*/*", r.Headers.Get("accept"))
 
 	// Test: Empty Headers
 	reader = &chunkReader{
@@ -171,8 +208,8 @@ func TestHeadersParse(t *testing.T) {
 	r, err = RequestFromReader(reader)
 	require.NoError(t, err)
 	require.NotNil(t, r)
-	assert.Equal(t, "test-head, head-test", r.Headers["test"])
-	assert.Equal(t, "*/*", r.Headers["accept"])
+	assert.Equal(t, "test-head, head-test", r.Headers.Get("test"))
+	assert.Equal(t, "*/*", r.Headers.Get("accept"))
 
 	// Test: Missing end of headers
 	reader = &chunkReader{
@@ -248,3 +285,64 @@ func TestBodyParse(t *testing.T) {
 	require.NotNil(t, r)
 	assert.Equal(t, "", string(r.Body))
 }
+
+func TestBufferedParse(t *testing.T) {
+	// Test: Bytes after a request without body are kept, the request is 41
+	// bytes long so the last 2 byte read also carries the next request's first
+	reader := &chunkReader{
+		data:            "GET / HTTP/1.1\r\nHost: localhost:42069\r\n\r\nGET /next HTTP/1.1\r\n",
+		numBytesPerRead: 2,
+	}
+	r, err := RequestFromReader(reader)
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, "G", string(r.Buffered()))
+
+	// Test: Bytes after a Content-Length body are kept the same way
+	reader = &chunkReader{
+		data: "POST /submit HTTP/1.1\r\n" +
+			"Content-Length: 5\r\n" +
+			"\r\n" +
+			"helloGET /next HTTP/1.1\r\n",
+		numBytesPerRead: 2,
+	}
+	r, err = RequestFromReader(reader)
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, "hello", string(r.Body))
+	assert.Equal(t, "G", string(r.Buffered()))
+
+	// Test: Bytes after the body of an upgrade are not part of the body
+	reader = &chunkReader{
+		data: "POST /submit HTTP/1.1\r\n" +
+			"Content-Length: 5\r\n" +
+			"Connection: Upgrade\r\n" +
+			"Upgrade: example\r\n" +
+			"\r\n" +
+			"helloworld",
+		numBytesPerRead: 100,
+	}
+	r, err = RequestFromReader(reader)
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, "hello", string(r.Body))
+	assert.Equal(t, "world", string(r.Buffered()))
+
+	// Test: Nothing buffered when the request ends with the read
+	reader = &chunkReader{
+		data:            "GET / HTTP/1.1\r\n\r\n",
+		numBytesPerRead: 100,
+	}
+	r, err = RequestFromReader(reader)
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Empty(t, r.Buffered())
+
+	// Test: Negative Content-Length
+	reader = &chunkReader{
+		data:            "POST /submit HTTP/1.1\r\nContent-Length: -1\r\n\r\n",
+		numBytesPerRead: 100,
+	}
+	_, err = RequestFromReader(reader)
+	require.Error(t, err)
+}
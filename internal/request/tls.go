@@ -0,0 +1,12 @@
+package request
+
+import "crypto/x509"
+
+// VerifiedChain returns the client certificate chain verified during the TLS
+// handshake, leaf first, or nil when the client sent no verified certificate.
+func (r *Request) VerifiedChain() []*x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0]
+}
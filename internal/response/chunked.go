@@ -0,0 +1,83 @@
+package response
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+)
+
+// ChunkExtension is a name and optional value sent after a chunk size.
+type ChunkExtension struct {
+	Name  string
+	Value string
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func formatExtensions(extensions []ChunkExtension) (string, error) {
+	var sb strings.Builder
+	for _, ext := range extensions {
+		if !isToken(ext.Name) {
+			return "", fmt.Errorf("Error: invalid chunk extension name %q", ext.Name)
+		}
+		sb.WriteString(";")
+		sb.WriteString(ext.Name)
+		if ext.Value == "" {
+			continue
+		}
+		if strings.ContainsFunc(ext.Value, isControl) {
+			return "", fmt.Errorf("Error: invalid chunk extension value for %q", ext.Name)
+		}
+		sb.WriteString("=")
+		if isToken(ext.Value) {
+			sb.WriteString(ext.Value)
+		} else {
+			sb.WriteString(`"` + quoteEscaper.Replace(ext.Value) + `"`)
+		}
+	}
+	return sb.String(), nil
+}
+
+// isControl reports control characters, which can't appear in a
+// quoted-string except for HTAB.
+func isControl(c rune) bool {
+	return (c < ' ' && c != '\t') || c == 0x7f
+}
+
+func isToken(s string) bool {
+	for _, c := range s {
+		if c > 127 || !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || strings.ContainsRune("!#$%&'*+-.^_`|~", c)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// ChunkedWriter writes a chunked body through the io.Writer interface so it
+// can be used with io.Copy. Close sends the last chunk followed by Trailers.
+type ChunkedWriter struct {
+	Trailers headers.Headers
+
+	w *Writer
+}
+
+// NewChunkedWriter returns a ChunkedWriter for w, whose headers must already
+// declare Transfer-Encoding: chunked.
+func NewChunkedWriter(w *Writer) *ChunkedWriter {
+	return &ChunkedWriter{
+		Trailers: headers.NewHeaders(),
+		w:        w,
+	}
+}
+
+func (c *ChunkedWriter) Write(p []byte) (int, error) {
+	return c.w.WriteChunkedBody(p)
+}
+
+func (c *ChunkedWriter) Close() error {
+	if _, err := c.w.WriteChunkedBodyDone(); err != nil {
+		return err
+	}
+	return c.w.WriteTrailers(c.Trailers)
+}
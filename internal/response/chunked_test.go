@@ -0,0 +1,96 @@
+package response
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct {
+	fail bool
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.fail {
+		return 0, errors.New("Error: write failed")
+	}
+	return len(p), nil
+}
+
+func chunkedHeaders() headers.Headers {
+	h := GetDefaultHeaders(0)
+	h.Delete("Content-Length")
+	h.Set("Transfer-Encoding", "chunked")
+	return h
+}
+
+func TestChunkedBody(t *testing.T) {
+	// Test: Empty chunks don't end the body
+	var buff bytes.Buffer
+	w := NewResponseWriter(&buff)
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(chunkedHeaders()))
+	n, err := w.WriteChunkedBody([]byte{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	n, err = w.WriteChunkedBody([]byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.True(t, strings.HasSuffix(buff.String(), "\r\n\r\n5\r\nhello\r\n"))
+
+	// Test: Chunk extensions
+	_, err = w.WriteChunkedBody([]byte("hi"), ChunkExtension{Name: "last"}, ChunkExtension{Name: "sig", Value: "ab cd"}, ChunkExtension{Name: "n", Value: "1"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(buff.String(), "2;last;sig=\"ab cd\";n=1\r\nhi\r\n"))
+
+	// Test: Extension names must be tokens
+	before := buff.Len()
+	_, err = w.WriteChunkedBody([]byte("hi"), ChunkExtension{Name: "bad name"})
+	require.Error(t, err)
+	_, err = w.WriteChunkedBody([]byte("hi"), ChunkExtension{Name: "x\r\n0"})
+	require.Error(t, err)
+	assert.Equal(t, before, buff.Len())
+
+	// Test: Extension values can't carry control characters
+	_, err = w.WriteChunkedBody([]byte("hi"), ChunkExtension{Name: "a", Value: "x\r\n0\r\nEvil: 1\r\n\r\n"})
+	require.Error(t, err)
+	_, err = w.WriteChunkedBody([]byte("hi"), ChunkExtension{Name: "a", Value: "x\x00"})
+	require.Error(t, err)
+	assert.Equal(t, before, buff.Len())
+	_, err = w.WriteChunkedBody([]byte("hi"), ChunkExtension{Name: "a", Value: "x\ty"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(buff.String(), "2;a=\"x\ty\"\r\nhi\r\n"))
+
+	// Test: Write errors are returned
+	conn := &failingWriter{}
+	w = NewResponseWriter(conn)
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(chunkedHeaders()))
+	conn.fail = true
+	n, err = w.WriteChunkedBody([]byte("hello"))
+	require.Error(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestChunkedWriter(t *testing.T) {
+	// Test: io.Copy writes chunks then last chunk and trailers
+	var buff bytes.Buffer
+	w := NewResponseWriter(&buff)
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(chunkedHeaders()))
+
+	body := NewChunkedWriter(w)
+	n, err := io.Copy(body, strings.NewReader("hello world"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), n)
+	body.Trailers.Set("X-Checksum", "abc")
+	require.NoError(t, body.Close())
+	require.NoError(t, w.Close())
+	assert.True(t, strings.HasSuffix(buff.String(), "\r\n\r\nb\r\nhello world\r\n0\r\nx-checksum: abc\r\n\r\n"))
+}
@@ -0,0 +1,142 @@
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/Quak1/learn-http-go/internal/request"
+)
+
+func StrongETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func WeakETag(size int64, modtime time.Time) string {
+	return fmt.Sprintf(`W/"%x-%x"`, size, modtime.UnixNano())
+}
+
+// FileETag is a strong ETag for file content from its size and modification
+// time, which changes whenever the file is rewritten without reading it.
+func FileETag(size int64, modtime time.Time) string {
+	return fmt.Sprintf(`"%x-%x"`, size, modtime.UnixNano())
+}
+
+// EvaluatePreconditions applies the conditional request headers in the order
+// given by RFC 9110 section 13.2.2. It returns the status to reply with and
+// false when the request should not be processed any further.
+func EvaluatePreconditions(req *request.Request, etag string, modtime time.Time) (StatusCode, bool) {
+	method := req.RequestLine.Method
+	safe := method == "GET" || method == "HEAD"
+	modtime = modtime.Truncate(time.Second)
+
+	if ifMatch := req.Headers.Get("If-Match"); ifMatch != "" {
+		if !etagListMatches(ifMatch, etag, true) {
+			return StatusPreconditionFailed, false
+		}
+	} else if since := req.Headers.Get("If-Unmodified-Since"); since != "" && !modtime.IsZero() {
+		t, err := headers.ParseHTTPDate(since)
+		if err == nil && modtime.After(t) {
+			return StatusPreconditionFailed, false
+		}
+	}
+
+	if ifNoneMatch := req.Headers.Get("If-None-Match"); ifNoneMatch != "" {
+		if etagListMatches(ifNoneMatch, etag, false) {
+			if safe {
+				return StatusNotModified, false
+			}
+			return StatusPreconditionFailed, false
+		}
+	} else if since := req.Headers.Get("If-Modified-Since"); since != "" && safe && !modtime.IsZero() {
+		t, err := headers.ParseHTTPDate(since)
+		if err == nil && !modtime.After(t) {
+			return StatusNotModified, false
+		}
+	}
+
+	return StatusOK, true
+}
+
+// CheckPreconditions writes a 304 or 412 response when the request's
+// conditions fail, returning false so the handler can stop there.
+func CheckPreconditions(w *Writer, req *request.Request, etag string, modtime time.Time) bool {
+	status, ok := EvaluatePreconditions(req, etag, modtime)
+	if ok {
+		return true
+	}
+
+	h := GetDefaultHeaders(0)
+	h.Delete("Content-Type")
+	if status == StatusNotModified {
+		h.Delete("Content-Length")
+		if etag != "" {
+			h.Set("ETag", etag)
+		}
+		if !modtime.IsZero() {
+			h.SetTime("Last-Modified", modtime)
+		}
+	}
+
+	w.WriteStatusLine(status)
+	w.WriteHeaders(h)
+	return false
+}
+
+func etagListMatches(list, etag string, strong bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return etag != ""
+	}
+	if etag == "" {
+		return false
+	}
+
+	for _, candidate := range parseETagList(list) {
+		if strong && etagsStrongMatch(candidate, etag) {
+			return true
+		}
+		if !strong && etagsWeakMatch(candidate, etag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func etagsStrongMatch(a, b string) bool {
+	return !strings.HasPrefix(a, "W/") && !strings.HasPrefix(b, "W/") && a == b
+}
+
+func etagsWeakMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+func parseETagList(list string) []string {
+	var etags []string
+	for {
+		list = strings.TrimLeft(list, " \t,")
+		if list == "" {
+			return etags
+		}
+
+		prefix := ""
+		if strings.HasPrefix(list, "W/") {
+			prefix = "W/"
+			list = list[2:]
+		}
+		if !strings.HasPrefix(list, `"`) {
+			return etags
+		}
+
+		end := strings.IndexByte(list[1:], '"')
+		if end == -1 {
+			return etags
+		}
+		etags = append(etags, prefix+list[:end+2])
+		list = list[end+2:]
+	}
+}
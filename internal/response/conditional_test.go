@@ -0,0 +1,99 @@
+package response
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func evaluate(t *testing.T, raw, etag string, modtime time.Time) (StatusCode, bool) {
+	req, err := request.RequestFromReader(strings.NewReader(raw))
+	require.NoError(t, err)
+	return EvaluatePreconditions(req, etag, modtime)
+}
+
+func TestETags(t *testing.T) {
+	// Test: Strong ETag is stable and quoted
+	etag := StrongETag([]byte("hello"))
+	assert.Equal(t, etag, StrongETag([]byte("hello")))
+	assert.NotEqual(t, etag, StrongETag([]byte("world")))
+	assert.True(t, strings.HasPrefix(etag, `"`) && strings.HasSuffix(etag, `"`))
+
+	// Test: Weak ETag
+	assert.True(t, strings.HasPrefix(WeakETag(10, time.Unix(1, 0)), `W/"`))
+
+	// Test: ETag list parsing with commas inside tags
+	assert.Equal(t, []string{`"a,b"`, `W/"c"`, `"d"`}, parseETagList(`"a,b", W/"c" ,"d"`))
+}
+
+func TestEvaluatePreconditions(t *testing.T) {
+	modtime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	before := modtime.Add(-time.Hour).Format(http.TimeFormat)
+	after := modtime.Add(time.Hour).Format(http.TimeFormat)
+	etag := `"v1"`
+
+	// Test: No conditions
+	status, ok := evaluate(t, "GET / HTTP/1.1\r\n\r\n", etag, modtime)
+	assert.True(t, ok)
+	assert.Equal(t, StatusOK, status)
+
+	// Test: If-None-Match matches on GET
+	status, ok = evaluate(t, "GET / HTTP/1.1\r\nIf-None-Match: \"v0\", W/\"v1\"\r\n\r\n", etag, modtime)
+	assert.False(t, ok)
+	assert.Equal(t, StatusNotModified, status)
+
+	// Test: If-None-Match matches on PUT
+	status, ok = evaluate(t, "PUT / HTTP/1.1\r\nIf-None-Match: *\r\n\r\n", etag, modtime)
+	assert.False(t, ok)
+	assert.Equal(t, StatusPreconditionFailed, status)
+
+	// Test: If-None-Match takes precedence over If-Modified-Since
+	status, ok = evaluate(t, "GET / HTTP/1.1\r\nIf-None-Match: \"v0\"\r\nIf-Modified-Since: "+after+"\r\n\r\n", etag, modtime)
+	assert.True(t, ok)
+
+	// Test: If-Modified-Since not modified
+	status, ok = evaluate(t, "GET / HTTP/1.1\r\nIf-Modified-Since: "+after+"\r\n\r\n", etag, modtime)
+	assert.False(t, ok)
+	assert.Equal(t, StatusNotModified, status)
+
+	// Test: If-Modified-Since modified
+	_, ok = evaluate(t, "GET / HTTP/1.1\r\nIf-Modified-Since: "+before+"\r\n\r\n", etag, modtime)
+	assert.True(t, ok)
+
+	// Test: If-Match uses strong comparison
+	status, ok = evaluate(t, "PUT / HTTP/1.1\r\nIf-Match: W/\"v1\"\r\n\r\n", etag, modtime)
+	assert.False(t, ok)
+	assert.Equal(t, StatusPreconditionFailed, status)
+	_, ok = evaluate(t, "PUT / HTTP/1.1\r\nIf-Match: \"v1\"\r\n\r\n", etag, modtime)
+	assert.True(t, ok)
+
+	// Test: If-Unmodified-Since fails when modified later
+	status, ok = evaluate(t, "DELETE / HTTP/1.1\r\nIf-Unmodified-Since: "+before+"\r\n\r\n", etag, modtime)
+	assert.False(t, ok)
+	assert.Equal(t, StatusPreconditionFailed, status)
+
+	// Test: If-Match takes precedence over If-Unmodified-Since
+	_, ok = evaluate(t, "DELETE / HTTP/1.1\r\nIf-Match: \"v1\"\r\nIf-Unmodified-Since: "+before+"\r\n\r\n", etag, modtime)
+	assert.True(t, ok)
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	req, err := request.RequestFromReader(strings.NewReader("GET / HTTP/1.1\r\nIf-None-Match: \"v1\"\r\n\r\n"))
+	require.NoError(t, err)
+
+	// Test: 304 carries the validators and no body
+	var buff bytes.Buffer
+	ok := CheckPreconditions(NewResponseWriter(&buff), req, `"v1"`, time.Time{})
+	assert.False(t, ok)
+	out := buff.String()
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 304 Not Modified\r\n"))
+	assert.Contains(t, out, "etag: \"v1\"\r\n")
+	assert.NotContains(t, out, "content-length")
+	assert.True(t, strings.HasSuffix(out, "\r\n\r\n"))
+}
@@ -0,0 +1,26 @@
+package response
+
+import (
+	"github.com/Quak1/learn-http-go/internal/headers"
+)
+
+// WriteError writes a complete plain text response for status, with the
+// reason phrase as body and extra added to the default headers.
+func WriteError(w *Writer, status StatusCode, extra headers.Headers) error {
+	body := getStatusReason(status) + "\n"
+
+	if err := w.WriteStatusLine(status); err != nil {
+		return err
+	}
+	h := GetDefaultHeaders(len(body))
+	for k, values := range extra {
+		for _, v := range values {
+			h.Set(k, v)
+		}
+	}
+	if err := w.WriteHeaders(h); err != nil {
+		return err
+	}
+	_, err := w.WriteBody([]byte(body))
+	return err
+}
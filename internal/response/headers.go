@@ -2,6 +2,8 @@ package response
 
 import (
 	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/Quak1/learn-http-go/internal/headers"
 )
@@ -13,3 +15,26 @@ func GetDefaultHeaders(contentLen int) headers.Headers {
 	h.Set("Content-Type", "text/plain")
 	return h
 }
+
+type cachedDate struct {
+	unix  int64
+	value string
+}
+
+var dateCache atomic.Pointer[cachedDate]
+
+// currentDate returns the IMF-fixdate for the Date header, formatting it at
+// most once per second.
+func currentDate() string {
+	now := time.Now()
+	if c := dateCache.Load(); c != nil && c.unix == now.Unix() {
+		return c.value
+	}
+
+	c := &cachedDate{
+		unix:  now.Unix(),
+		value: headers.FormatHTTPDate(now),
+	}
+	dateCache.Store(c)
+	return c.value
+}
@@ -0,0 +1,46 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+)
+
+// WriteJSON writes v as a JSON response with the given status. Nothing is
+// written when v can't be encoded.
+func (w *Writer) WriteJSON(status StatusCode, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	body = append(body, '\n')
+
+	if err := w.WriteStatusLine(status); err != nil {
+		return err
+	}
+	h := GetDefaultHeaders(len(body))
+	h.Replace("Content-Type", "application/json")
+	if err := w.WriteHeaders(h); err != nil {
+		return err
+	}
+	_, err = w.WriteBody(body)
+	return err
+}
+
+// ReadJSON decodes the request body into v, replying 415, 413 or 400 and
+// returning false when it can't.
+func ReadJSON(w *Writer, req *request.Request, v any, maxSize int64) bool {
+	err := req.DecodeJSON(v, maxSize)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, request.ErrNotJSON):
+		w.WriteJSON(StatusUnsupportedMedia, map[string]string{"error": err.Error()})
+	case errors.Is(err, request.ErrBodyTooLarge):
+		w.WriteJSON(StatusContentTooLarge, map[string]string{"error": err.Error()})
+	default:
+		w.WriteJSON(StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return false
+}
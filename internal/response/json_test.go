@@ -0,0 +1,63 @@
+package response
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSON(t *testing.T) {
+	// Test: Value is encoded with matching headers
+	var buff bytes.Buffer
+	w := NewResponseWriter(&buff)
+	require.NoError(t, w.WriteJSON(StatusOK, map[string]int{"count": 1}))
+	out := buff.String()
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n"))
+	assert.Contains(t, out, "content-type: application/json\r\n")
+	assert.Contains(t, out, "content-length: 12\r\n")
+	assert.True(t, strings.HasSuffix(out, "\r\n\r\n{\"count\":1}\n"))
+
+	// Test: Unencodable value writes nothing
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	require.Error(t, w.WriteJSON(StatusOK, make(chan int)))
+	assert.Empty(t, buff.String())
+}
+
+func TestReadJSON(t *testing.T) {
+	read := func(raw string) (bool, string) {
+		req, err := request.RequestFromReader(strings.NewReader(raw))
+		require.NoError(t, err)
+
+		var buff bytes.Buffer
+		var v struct {
+			Name string `json:"name"`
+		}
+		ok := ReadJSON(NewResponseWriter(&buff), req, &v, 32)
+		return ok, buff.String()
+	}
+
+	// Test: Valid body
+	ok, out := read("POST / HTTP/1.1\r\nContent-Type: application/json\r\nContent-Length: 12\r\n\r\n{\"name\":\"a\"}")
+	assert.True(t, ok)
+	assert.Empty(t, out)
+
+	// Test: Wrong Content-Type
+	ok, out = read("POST / HTTP/1.1\r\nContent-Type: text/plain\r\nContent-Length: 12\r\n\r\n{\"name\":\"a\"}")
+	assert.False(t, ok)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 415 Unsupported Media Type\r\n"))
+
+	// Test: Body too large
+	ok, out = read("POST / HTTP/1.1\r\nContent-Type: application/json\r\nContent-Length: 40\r\n\r\n{\"name\":\"aaaaaaaaaaaaaaaaaaaaaaaaaaaaa\"}")
+	assert.False(t, ok)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 413 Content Too Large\r\n"))
+
+	// Test: Unknown field
+	ok, out = read("POST / HTTP/1.1\r\nContent-Type: application/json\r\nContent-Length: 9\r\n\r\n{\"age\":1}")
+	assert.False(t, ok)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 400 Bad Request\r\n"))
+}
@@ -0,0 +1,274 @@
+package response
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/Quak1/learn-http-go/internal/request"
+)
+
+var (
+	ErrInvalidRange        = errors.New("Error: invalid Range header")
+	ErrRangeNotSatisfiable = errors.New("Error: no satisfiable range")
+)
+
+// maxRanges is the most ranges a single Range header may ask for before it
+// is ignored.
+const maxRanges = 16
+
+type ByteRange struct {
+	Start  int64
+	Length int64
+}
+
+func (r ByteRange) ContentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.Start, r.Start+r.Length-1, size)
+}
+
+// ParseRange parses a Range header value against a representation of the
+// given size. A nil slice without error means the header should be ignored,
+// which is also the case for more than maxRanges ranges. Overlapping and
+// adjacent ranges are coalesced and the result is sorted by offset.
+func ParseRange(s string, size int64) ([]ByteRange, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	spec, ok := strings.CutPrefix(s, "bytes=")
+	if !ok {
+		return nil, nil
+	}
+
+	var ranges []ByteRange
+	count := 0
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		count++
+		if count > maxRanges {
+			return nil, nil
+		}
+
+		first, last, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, ErrInvalidRange
+		}
+		first = strings.TrimSpace(first)
+		last = strings.TrimSpace(last)
+
+		if first == "" {
+			suffix, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || suffix < 0 {
+				return nil, ErrInvalidRange
+			}
+			if suffix == 0 || size == 0 {
+				continue
+			}
+			suffix = min(suffix, size)
+			ranges = append(ranges, ByteRange{Start: size - suffix, Length: suffix})
+			continue
+		}
+
+		start, err := strconv.ParseInt(first, 10, 64)
+		if err != nil || start < 0 {
+			return nil, ErrInvalidRange
+		}
+
+		end := size - 1
+		if last != "" {
+			end, err = strconv.ParseInt(last, 10, 64)
+			if err != nil || end < start {
+				return nil, ErrInvalidRange
+			}
+			end = min(end, size-1)
+		}
+
+		if start >= size {
+			continue
+		}
+		ranges = append(ranges, ByteRange{Start: start, Length: end - start + 1})
+	}
+
+	if len(ranges) == 0 {
+		return nil, ErrRangeNotSatisfiable
+	}
+
+	return coalesce(ranges), nil
+}
+
+func coalesce(ranges []ByteRange) []ByteRange {
+	slices.SortFunc(ranges, func(a, b ByteRange) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		end := last.Start + last.Length
+		if r.Start > end {
+			merged = append(merged, r)
+			continue
+		}
+		last.Length = max(end, r.Start+r.Length) - last.Start
+	}
+	return merged
+}
+
+// ServeContent writes content honoring conditional headers, Range and
+// If-Range, replying with the whole representation, a single 206 part, or a
+// multipart/byteranges body.
+func ServeContent(w *Writer, req *request.Request, contentType string, modtime time.Time, content io.ReadSeeker) error {
+	size, err := content.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	etag := ""
+	if !modtime.IsZero() {
+		etag = FileETag(size, modtime)
+	}
+	if !CheckPreconditions(w, req, etag, modtime) {
+		return nil
+	}
+
+	h := GetDefaultHeaders(int(size))
+	h.Replace("Content-Type", contentType)
+	h.Set("Accept-Ranges", "bytes")
+	if !modtime.IsZero() {
+		h.Set("ETag", etag)
+		h.SetTime("Last-Modified", modtime)
+	}
+
+	var ranges []ByteRange
+	if req.RequestLine.Method == "GET" && ifRangeMatches(req, etag, modtime) {
+		ranges, err = ParseRange(req.Headers.Get("Range"), size)
+		if errors.Is(err, ErrRangeNotSatisfiable) {
+			h.Replace("Content-Length", "0")
+			h.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			if err := w.WriteStatusLine(StatusRangeNotSatisfiable); err != nil {
+				return err
+			}
+			return w.WriteHeaders(h)
+		}
+		if err != nil {
+			ranges = nil
+		}
+	}
+
+	switch len(ranges) {
+	case 0:
+		if err := w.WriteStatusLine(StatusOK); err != nil {
+			return err
+		}
+		if err := w.WriteHeaders(h); err != nil {
+			return err
+		}
+		return copyRange(w, content, ByteRange{Start: 0, Length: size})
+	case 1:
+		h.Replace("Content-Length", strconv.FormatInt(ranges[0].Length, 10))
+		h.Set("Content-Range", ranges[0].ContentRange(size))
+		if err := w.WriteStatusLine(StatusPartialContent); err != nil {
+			return err
+		}
+		if err := w.WriteHeaders(h); err != nil {
+			return err
+		}
+		return copyRange(w, content, ranges[0])
+	}
+
+	boundary := randomBoundary()
+	partHeaders := make([]string, len(ranges))
+	contentLength := int64(0)
+	for i, r := range ranges {
+		partHeaders[i] = fmt.Sprintf("\r\n--%s\r\nContent-Type: %s\r\nContent-Range: %s\r\n\r\n", boundary, contentType, r.ContentRange(size))
+		contentLength += int64(len(partHeaders[i])) + r.Length
+	}
+	closing := "\r\n--" + boundary + "--\r\n"
+	contentLength += int64(len(closing))
+
+	h.Replace("Content-Length", strconv.FormatInt(contentLength, 10))
+	h.Replace("Content-Type", "multipart/byteranges; boundary="+boundary)
+	if err := w.WriteStatusLine(StatusPartialContent); err != nil {
+		return err
+	}
+	if err := w.WriteHeaders(h); err != nil {
+		return err
+	}
+
+	for i, r := range ranges {
+		if _, err := w.WriteBody([]byte(partHeaders[i])); err != nil {
+			return err
+		}
+		if err := copyRange(w, content, r); err != nil {
+			return err
+		}
+	}
+	_, err = w.WriteBody([]byte(closing))
+	return err
+}
+
+func ifRangeMatches(req *request.Request, etag string, modtime time.Time) bool {
+	ifRange := req.Headers.Get("If-Range")
+	if ifRange == "" {
+		return true
+	}
+
+	if strings.HasPrefix(ifRange, `"`) {
+		return etag != "" && ifRange == etag
+	}
+
+	t, err := headers.ParseHTTPDate(ifRange)
+	if err != nil || modtime.IsZero() {
+		return false
+	}
+	// Last-Modified is only a strong validator once it is at least a second
+	// older than Date, RFC 9110 section 8.8.2.2.
+	modtime = modtime.Truncate(time.Second)
+	if time.Now().Truncate(time.Second).Sub(modtime) < time.Second {
+		return false
+	}
+	return modtime.Equal(t)
+}
+
+func copyRange(w *Writer, content io.ReadSeeker, r ByteRange) error {
+	if _, err := content.Seek(r.Start, io.SeekStart); err != nil {
+		return err
+	}
+
+	buff := make([]byte, 32*1024)
+	remaining := r.Length
+	for remaining > 0 {
+		n, err := content.Read(buff[:min(int64(len(buff)), remaining)])
+		if n > 0 {
+			if _, err := w.WriteBody(buff[:n]); err != nil {
+				return err
+			}
+			remaining -= int64(n)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) && remaining == 0 {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func randomBoundary() string {
+	b := make([]byte, 15)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
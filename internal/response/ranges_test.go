@@ -0,0 +1,129 @@
+package response
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRange(t *testing.T) {
+	// Test: Single range
+	ranges, err := ParseRange("bytes=0-4", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []ByteRange{{Start: 0, Length: 5}}, ranges)
+
+	// Test: Open ended and suffix ranges
+	ranges, err = ParseRange("bytes=7-, -5", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []ByteRange{{Start: 5, Length: 5}}, ranges)
+
+	// Test: Overlapping and adjacent ranges are coalesced and sorted
+	ranges, err = ParseRange("bytes=0-,0-,0-", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []ByteRange{{Start: 0, Length: 10}}, ranges)
+	ranges, err = ParseRange("bytes=6-7,0-1,2-3,8-8", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []ByteRange{{Start: 0, Length: 4}, {Start: 6, Length: 3}}, ranges)
+
+	// Test: Too many ranges are ignored
+	ranges, err = ParseRange("bytes="+strings.Repeat("0-0,", maxRanges+1), 10)
+	require.NoError(t, err)
+	assert.Nil(t, ranges)
+
+	// Test: End past the size is clamped
+	ranges, err = ParseRange("bytes=5-100", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []ByteRange{{Start: 5, Length: 5}}, ranges)
+
+	// Test: Suffix longer than the size
+	ranges, err = ParseRange("bytes=-20", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []ByteRange{{Start: 0, Length: 10}}, ranges)
+
+	// Test: Unsatisfiable range
+	_, err = ParseRange("bytes=10-20", 10)
+	require.ErrorIs(t, err, ErrRangeNotSatisfiable)
+
+	// Test: Invalid range
+	_, err = ParseRange("bytes=5-1", 10)
+	require.ErrorIs(t, err, ErrInvalidRange)
+
+	// Test: Unknown unit is ignored
+	ranges, err = ParseRange("items=0-1", 10)
+	require.NoError(t, err)
+	assert.Nil(t, ranges)
+}
+
+func serveContent(t *testing.T, raw string, modtime time.Time) string {
+	req, err := request.RequestFromReader(strings.NewReader(raw))
+	require.NoError(t, err)
+
+	var buff bytes.Buffer
+	err = ServeContent(NewResponseWriter(&buff), req, "text/plain", modtime, strings.NewReader("0123456789"))
+	require.NoError(t, err)
+	return buff.String()
+}
+
+func TestServeContent(t *testing.T) {
+	modtime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// Test: Full content without Range
+	out := serveContent(t, "GET /video HTTP/1.1\r\n\r\n", modtime)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n"))
+	assert.Contains(t, out, "accept-ranges: bytes\r\n")
+	assert.True(t, strings.HasSuffix(out, "\r\n\r\n0123456789"))
+
+	// Test: Single range
+	out = serveContent(t, "GET /video HTTP/1.1\r\nRange: bytes=2-5\r\n\r\n", modtime)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 206 Partial Content\r\n"))
+	assert.Contains(t, out, "content-range: bytes 2-5/10\r\n")
+	assert.Contains(t, out, "content-length: 4\r\n")
+	assert.True(t, strings.HasSuffix(out, "\r\n\r\n2345"))
+
+	// Test: Multiple ranges
+	out = serveContent(t, "GET /video HTTP/1.1\r\nRange: bytes=0-1, -2\r\n\r\n", modtime)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 206 Partial Content\r\n"))
+	assert.Contains(t, out, "content-type: multipart/byteranges; boundary=")
+	assert.Contains(t, out, "Content-Range: bytes 0-1/10\r\n\r\n01")
+	assert.Contains(t, out, "Content-Range: bytes 8-9/10\r\n\r\n89")
+
+	headerEnd := strings.Index(out, "\r\n\r\n") + 4
+	resp, err := http.ReadResponse(bufio.NewReader(strings.NewReader(out)), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(out)-headerEnd), resp.ContentLength)
+
+	// Test: Unsatisfiable range
+	out = serveContent(t, "GET /video HTTP/1.1\r\nRange: bytes=20-\r\n\r\n", modtime)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 416 Range Not Satisfiable\r\n"))
+	assert.Contains(t, out, "content-range: bytes */10\r\n")
+
+	// Test: If-Range with matching date honors the range
+	out = serveContent(t, "GET /video HTTP/1.1\r\nRange: bytes=0-0\r\nIf-Range: "+modtime.Format(http.TimeFormat)+"\r\n\r\n", modtime)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 206 Partial Content\r\n"))
+
+	// Test: If-Range with the ETag honors the range
+	out = serveContent(t, "GET /video HTTP/1.1\r\nRange: bytes=0-0\r\nIf-Range: "+FileETag(10, modtime)+"\r\n\r\n", modtime)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 206 Partial Content\r\n"))
+	assert.Contains(t, out, "etag: "+FileETag(10, modtime)+"\r\n")
+
+	// Test: If-Match with the ETag is served
+	out = serveContent(t, "GET /video HTTP/1.1\r\nIf-Match: "+FileETag(10, modtime)+"\r\n\r\n", modtime)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n"))
+
+	// Test: If-Range with a date within a second of now is not a strong match
+	recent := time.Now()
+	out = serveContent(t, "GET /video HTTP/1.1\r\nRange: bytes=0-0\r\nIf-Range: "+headers.FormatHTTPDate(recent)+"\r\n\r\n", recent)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n"))
+
+	// Test: If-Range with stale date sends the whole content
+	out = serveContent(t, "GET /video HTTP/1.1\r\nRange: bytes=0-0\r\nIf-Range: "+modtime.Add(-time.Hour).Format(http.TimeFormat)+"\r\n\r\n", modtime)
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n"))
+}
@@ -0,0 +1,155 @@
+package response
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+)
+
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+type EventStream struct {
+	w             *Writer
+	lastEventID   string
+	mu            sync.Mutex
+	stopHeartbeat chan struct{}
+}
+
+func NewEventStream(w *Writer, req *request.Request) (*EventStream, error) {
+	if err := w.WriteStatusLine(StatusOK); err != nil {
+		return nil, err
+	}
+
+	h := GetDefaultHeaders(0)
+	h.Delete("Content-Length")
+	h.Replace("Content-Type", "text/event-stream")
+	h.Set("Transfer-Encoding", "chunked")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	if err := w.WriteHeaders(h); err != nil {
+		return nil, err
+	}
+
+	s := &EventStream{
+		w:           w,
+		lastEventID: req.Headers.Get("Last-Event-ID"),
+	}
+	return s, w.Flush()
+}
+
+// LastEventID is the id the client last received, sent back when it
+// reconnects so the stream can resume from there.
+func (s *EventStream) LastEventID() string {
+	return s.lastEventID
+}
+
+func (s *EventStream) Send(e Event) error {
+	b, err := encodeEvent(e)
+	if err != nil {
+		return err
+	}
+	return s.write(b)
+}
+
+func (s *EventStream) Comment(text string) error {
+	var b strings.Builder
+	for _, line := range splitLines(text) {
+		b.WriteString(": " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write([]byte(b.String()))
+}
+
+func (s *EventStream) StartHeartbeat(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopHeartbeat != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.stopHeartbeat = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := s.Comment("heartbeat"); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (s *EventStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopHeartbeat != nil {
+		close(s.stopHeartbeat)
+		s.stopHeartbeat = nil
+	}
+
+	if _, err := s.w.WriteChunkedBodyDone(); err != nil {
+		return err
+	}
+	if err := s.w.WriteTrailers(nil); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+func (s *EventStream) write(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.WriteChunkedBody(b); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+func encodeEvent(e Event) ([]byte, error) {
+	if strings.ContainsAny(e.Event, "\r\n") {
+		return nil, fmt.Errorf("Error: event name cannot contain newlines")
+	}
+	if strings.ContainsAny(e.ID, "\r\n\x00") {
+		return nil, fmt.Errorf("Error: event id cannot contain newlines or NULL")
+	}
+
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + e.Event + "\n")
+	}
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range splitLines(e.Data) {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	return []byte(b.String()), nil
+}
+
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,59 @@
+package response
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeEvent(t *testing.T) {
+	// Test: All fields
+	b, err := encodeEvent(Event{ID: "42", Event: "update", Data: "hello", Retry: 3 * time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, "id: 42\nevent: update\nretry: 3000\ndata: hello\n\n", string(b))
+
+	// Test: Multiline data is split into data fields
+	b, err = encodeEvent(Event{Data: "line one\nline two\r\nline three\rline four"})
+	require.NoError(t, err)
+	assert.Equal(t, "data: line one\ndata: line two\ndata: line three\ndata: line four\n\n", string(b))
+
+	// Test: Invalid event name
+	_, err = encodeEvent(Event{Event: "bad\nname", Data: "x"})
+	require.Error(t, err)
+
+	// Test: Invalid id
+	_, err = encodeEvent(Event{ID: "bad\x00id", Data: "x"})
+	require.Error(t, err)
+}
+
+func TestEventStream(t *testing.T) {
+	req, err := request.RequestFromReader(strings.NewReader("GET /events HTTP/1.1\r\nLast-Event-ID: 7\r\n\r\n"))
+	require.NoError(t, err)
+
+	// Test: Stream headers, events and termination
+	var buff bytes.Buffer
+	s, err := NewEventStream(NewResponseWriter(&buff), req)
+	require.NoError(t, err)
+	assert.Equal(t, "7", s.LastEventID())
+
+	require.NoError(t, s.Send(Event{ID: "8", Data: "hi"}))
+	require.NoError(t, s.Comment("heartbeat"))
+	require.NoError(t, s.Close())
+
+	out := buff.String()
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n"))
+	assert.Contains(t, out, "content-type: text/event-stream\r\n")
+	assert.Contains(t, out, "transfer-encoding: chunked\r\n")
+	assert.NotContains(t, out, "content-length")
+	assert.Contains(t, out, "\r\n10\r\nid: 8\ndata: hi\n\n\r\n")
+	assert.Contains(t, out, "\r\nd\r\n: heartbeat\n\n\r\n")
+	assert.True(t, strings.HasSuffix(out, "0\r\n\r\n"))
+
+	// Test: Sending after close fails
+	require.Error(t, s.Send(Event{Data: "late"}))
+}
@@ -3,20 +3,65 @@ package response
 type StatusCode int
 
 const (
+	StatusSwitchingProtocols  StatusCode = 101
 	StatusOK                  StatusCode = 200
+	StatusPartialContent      StatusCode = 206
+	StatusMovedPermanently    StatusCode = 301
+	StatusNotModified         StatusCode = 304
 	StatusBadRequest          StatusCode = 400
+	StatusForbidden           StatusCode = 403
+	StatusNotFound            StatusCode = 404
+	StatusMethodNotAllowed    StatusCode = 405
+	StatusNotAcceptable       StatusCode = 406
+	StatusPreconditionFailed  StatusCode = 412
+	StatusContentTooLarge     StatusCode = 413
+	StatusUnsupportedMedia    StatusCode = 415
+	StatusRangeNotSatisfiable StatusCode = 416
+	StatusUpgradeRequired     StatusCode = 426
 	StatusInternalServerError StatusCode = 500
+	StatusBadGateway          StatusCode = 502
+	StatusServiceUnavailable  StatusCode = 503
 )
 
 func getStatusReason(statusCode StatusCode) string {
 	var reason string
 	switch statusCode {
+	case StatusSwitchingProtocols:
+		reason = "Switching Protocols"
 	case StatusOK:
 		reason = "OK"
+	case StatusPartialContent:
+		reason = "Partial Content"
+	case StatusMovedPermanently:
+		reason = "Moved Permanently"
+	case StatusNotModified:
+		reason = "Not Modified"
 	case StatusBadRequest:
 		reason = "Bad Request"
+	case StatusForbidden:
+		reason = "Forbidden"
+	case StatusNotFound:
+		reason = "Not Found"
+	case StatusMethodNotAllowed:
+		reason = "Method Not Allowed"
+	case StatusNotAcceptable:
+		reason = "Not Acceptable"
+	case StatusPreconditionFailed:
+		reason = "Precondition Failed"
+	case StatusContentTooLarge:
+		reason = "Content Too Large"
+	case StatusUnsupportedMedia:
+		reason = "Unsupported Media Type"
+	case StatusRangeNotSatisfiable:
+		reason = "Range Not Satisfiable"
+	case StatusUpgradeRequired:
+		reason = "Upgrade Required"
 	case StatusInternalServerError:
 		reason = "Internal Server Error"
+	case StatusBadGateway:
+		reason = "Bad Gateway"
+	case StatusServiceUnavailable:
+		reason = "Service Unavailable"
 	}
 
 	return reason
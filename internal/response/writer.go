@@ -1,8 +1,13 @@
 package response
 
 import (
+	"bufio"
 	"fmt"
 	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/Quak1/learn-http-go/internal/headers"
 )
@@ -16,9 +21,51 @@ const (
 	WriterStateTrailers
 )
 
+// HeaderHook runs right before the headers are written and may modify them.
+// Returning a non-nil encoder routes the body written with WriteBody through
+// it, the encoder writing its output to body which applies the framing from
+// the final headers. Encoders are closed by Close.
+type HeaderHook func(status StatusCode, h headers.Headers, body io.Writer) io.WriteCloser
+
 type Writer struct {
-	writer io.Writer
-	state  writerState
+	writer   io.Writer
+	conn     net.Conn
+	buffered []byte
+	state    writerState
+	hijacked bool
+	status   StatusCode
+	hooks    []HeaderHook
+	body     io.Writer
+	encoders []io.WriteCloser
+	closed   bool
+
+	serverHeader   string
+	discardBody    bool
+	discarded      int
+	pendingHeaders headers.Headers
+	pendingBody    []byte
+
+	strict            bool
+	declaredLength    int
+	written           int
+	chunked           bool
+	announcedTrailers []string
+	trailersDone      bool
+
+	bytesWritten int64
+}
+
+// inferLengthLimit is how much body is held back when a handler declares no
+// framing, a body that fits is sent with a computed Content-Length and a
+// larger one is chunked.
+const inferLengthLimit = 4096
+
+type bodySink struct {
+	w *Writer
+}
+
+func (b bodySink) Write(p []byte) (int, error) {
+	return b.w.writeRawBody(p)
 }
 
 func NewResponseWriter(w io.Writer) *Writer {
@@ -28,6 +75,138 @@ func NewResponseWriter(w io.Writer) *Writer {
 	}
 }
 
+func NewConnResponseWriter(conn net.Conn, buffered []byte) *Writer {
+	return &Writer{
+		writer:   bufio.NewWriter(conn),
+		conn:     conn,
+		buffered: buffered,
+		state:    WriterStateStatusLine,
+	}
+}
+
+// Flush sends everything written so far. Held headers are committed with
+// Transfer-Encoding: chunked since the length is not known yet.
+func (w *Writer) Flush() error {
+	w.startChunked()
+	return w.flush()
+}
+
+func (w *Writer) flush() error {
+	if err := w.commitHeaders(); err != nil {
+		return err
+	}
+	if bw, ok := w.writer.(*bufio.Writer); ok {
+		return bw.Flush()
+	}
+	return nil
+}
+
+// Hijack flushes anything already written and hands the connection over to
+// the caller, the server won't write to or close it afterwards. The returned
+// bytes were already read from the connection after the request and must be
+// consumed before reading from it.
+func (w *Writer) Hijack() (net.Conn, []byte, error) {
+	if w.conn == nil {
+		return nil, nil, fmt.Errorf("Error: writer is not backed by a connection")
+	}
+	if w.hijacked {
+		return nil, nil, fmt.Errorf("Error: connection already hijacked")
+	}
+
+	if err := w.flush(); err != nil {
+		return nil, nil, err
+	}
+	w.hijacked = true
+
+	buffered := w.buffered
+	w.buffered = nil
+	return w.conn, buffered, nil
+}
+
+func (w *Writer) Hijacked() bool {
+	return w.hijacked
+}
+
+// BytesWritten returns the number of body bytes sent so far, after any
+// content coding and without chunk framing.
+func (w *Writer) BytesWritten() int64 {
+	return w.bytesWritten
+}
+
+func (w *Writer) Status() StatusCode {
+	return w.status
+}
+
+// DiscardBody makes the writer drop body bytes while still writing the status
+// line and headers, as needed to answer HEAD requests with GET handlers. When
+// the handler declares no framing the headers are held until Close so the
+// Content-Length can be computed from what would have been written.
+func (w *Writer) DiscardBody() {
+	w.discardBody = true
+}
+
+// StrictFraming makes the writer hold handlers to the framing they declare:
+// writing past Content-Length, leaving it short, using chunked writes without
+// Transfer-Encoding: chunked, or sending trailers not announced in the
+// Trailer header are errors. Bodies without a declared length are chunked.
+func (w *Writer) StrictFraming() {
+	w.strict = true
+}
+
+func (w *Writer) SetServerHeader(value string) {
+	w.serverHeader = value
+}
+
+func (w *Writer) AddHeaderHook(hook HeaderHook) {
+	w.hooks = append(w.hooks, hook)
+}
+
+// Close finishes the response body, closing any encoders installed by header
+// hooks. It is safe to call more than once.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	for i := len(w.encoders) - 1; i >= 0; i-- {
+		if err := w.encoders[i].Close(); err != nil {
+			return err
+		}
+	}
+
+	if w.pendingHeaders != nil {
+		length := len(w.pendingBody)
+		if w.discardBody {
+			length = w.discarded
+		}
+		w.pendingHeaders.Replace("Content-Length", strconv.Itoa(length))
+	}
+	if err := w.commitHeaders(); err != nil {
+		return err
+	}
+
+	if w.chunked && !w.discardBody {
+		if w.state == WriterStateBody {
+			if _, err := w.writer.Write([]byte("0\r\n")); err != nil {
+				return err
+			}
+			w.state = WriterStateTrailers
+		}
+		if w.state == WriterStateTrailers && !w.trailersDone {
+			w.trailersDone = true
+			if _, err := w.writer.Write([]byte("\r\n")); err != nil {
+				return err
+			}
+		}
+	}
+
+	if w.strict && !w.discardBody && w.declaredLength >= 0 && w.written < w.declaredLength {
+		return fmt.Errorf("Error: body of %d bytes is shorter than Content-Length %d", w.written, w.declaredLength)
+	}
+	return nil
+}
+
 func (w *Writer) WriteStatusLine(statusCode StatusCode) error {
 	if w.state != WriterStateStatusLine {
 		return fmt.Errorf("Error: cannot write status line on state %d", w.state)
@@ -37,6 +216,7 @@ func (w *Writer) WriteStatusLine(statusCode StatusCode) error {
 	HTTPVersion := "HTTP/1.1"
 
 	_, err := fmt.Fprintf(w.writer, "%s %d %s\r\n", HTTPVersion, statusCode, reason)
+	w.status = statusCode
 	w.state = WriterStateHeaders
 
 	return err
@@ -47,51 +227,203 @@ func (w *Writer) WriteHeaders(headers headers.Headers) error {
 		return fmt.Errorf("Error: cannot write headers on state %d", w.state)
 	}
 
-	for v, k := range headers {
-		_, err := fmt.Fprintf(w.writer, "%s: %s\r\n", v, k)
-		if err != nil {
+	if headers == nil {
+		headers = make(map[string][]string)
+	}
+	if w.status >= 200 && headers.Get("Date") == "" {
+		headers.Set("Date", currentDate())
+	}
+	if w.serverHeader != "" && headers.Get("Server") == "" {
+		headers.Set("Server", w.serverHeader)
+	}
+
+	w.declaredLength = -1
+	if contentLength := headers.Get("Content-Length"); contentLength != "" {
+		n, err := strconv.Atoi(contentLength)
+		if err != nil || n < 0 {
+			if w.strict {
+				return fmt.Errorf("Error: invalid Content-Length %q", contentLength)
+			}
+		} else {
+			w.declaredLength = n
+		}
+	}
+
+	w.body = bodySink{w}
+	for _, hook := range w.hooks {
+		if encoder := hook(w.status, headers, w.body); encoder != nil {
+			w.encoders = append(w.encoders, encoder)
+			w.body = encoder
+		}
+	}
+
+	w.chunked = isChunked(headers.Get("Transfer-Encoding"))
+	if w.strict && w.canHaveBody() && !w.chunked && headers.Get("Content-Length") == "" {
+		headers.Set("Transfer-Encoding", "chunked")
+		w.chunked = true
+	}
+	for _, name := range strings.Split(headers.Get("Trailer"), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			w.announcedTrailers = append(w.announcedTrailers, name)
+		}
+	}
+
+	w.state = WriterStateBody
+	if w.canHaveBody() && headers.Get("Content-Length") == "" && headers.Get("Transfer-Encoding") == "" {
+		w.pendingHeaders = headers
+		return nil
+	}
+
+	return w.writeHeaderLines(headers)
+}
+
+func isChunked(transferEncoding string) bool {
+	codings := strings.Split(transferEncoding, ",")
+	return strings.EqualFold(strings.TrimSpace(codings[len(codings)-1]), "chunked")
+}
+
+func (w *Writer) canHaveBody() bool {
+	return w.status >= 200 && w.status != 204 && w.status != StatusNotModified
+}
+
+// startChunked switches held headers without a Content-Length to chunked
+// framing, for bodies that outgrow inferLengthLimit or are flushed early.
+func (w *Writer) startChunked() {
+	if w.pendingHeaders == nil || w.pendingHeaders.Get("Content-Length") != "" {
+		return
+	}
+	w.pendingHeaders.Set("Transfer-Encoding", "chunked")
+	w.chunked = true
+}
+
+func (w *Writer) commitHeaders() error {
+	if w.pendingHeaders == nil {
+		return nil
+	}
+
+	h := w.pendingHeaders
+	w.pendingHeaders = nil
+	if err := w.writeHeaderLines(h); err != nil {
+		return err
+	}
+
+	body := w.pendingBody
+	w.pendingBody = nil
+	if w.discardBody || len(body) == 0 {
+		return nil
+	}
+	if w.chunked {
+		_, err := w.writeChunk(body)
+		return err
+	}
+	n, err := w.writer.Write(body)
+	w.bytesWritten += int64(n)
+	return err
+}
+
+func (w *Writer) writeRawBody(p []byte) (int, error) {
+	if w.discardBody {
+		w.discarded += len(p)
+		return len(p), nil
+	}
+
+	if w.pendingHeaders != nil {
+		w.pendingBody = append(w.pendingBody, p...)
+		if len(w.pendingBody) > inferLengthLimit {
+			w.startChunked()
+			if err := w.commitHeaders(); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+
+	if w.chunked {
+		return w.writeChunk(p)
+	}
+	n, err := w.writer.Write(p)
+	w.bytesWritten += int64(n)
+	return n, err
+}
+
+// writeChunk frames p as a single chunk. Empty chunks are skipped since a
+// zero-length chunk would end the body.
+func (w *Writer) writeChunk(p []byte, extensions ...ChunkExtension) (int, error) {
+	ext, err := formatExtensions(extensions)
+	if err != nil {
+		return 0, err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if _, err := fmt.Fprintf(w.writer, "%x%s\r\n", len(p), ext); err != nil {
+		return 0, err
+	}
+	n, err := w.writer.Write(p)
+	w.bytesWritten += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.writer.Write([]byte("\r\n")); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *Writer) writeHeaderLines(headers headers.Headers) error {
+	for k, v := range headers {
+		if err := w.writeField(k, v); err != nil {
 			return err
 		}
 	}
 
 	_, err := w.writer.Write([]byte("\r\n"))
-	w.state = WriterStateBody
 	return err
 }
 
+func (w *Writer) writeField(key string, values []string) error {
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("Error: invalid value for field %q, contains CR or LF", key)
+		}
+		_, err := fmt.Fprintf(w.writer, "%s: %s\r\n", key, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (w *Writer) WriteBody(p []byte) (int, error) {
 	if w.state != WriterStateBody {
 		return 0, fmt.Errorf("Error: cannot write body on state %d", w.state)
 	}
 
-	return w.writer.Write(p)
+	if w.strict && w.declaredLength >= 0 && w.written+len(p) > w.declaredLength {
+		return 0, fmt.Errorf("Error: body exceeds Content-Length %d", w.declaredLength)
+	}
+	w.written += len(p)
+
+	return w.body.Write(p)
 }
 
-func (w *Writer) WriteChunkedBody(p []byte) (int, error) {
+// WriteChunkedBody writes p as one chunk, returning the number of bytes of p
+// written. Empty writes are ignored rather than ending the body.
+func (w *Writer) WriteChunkedBody(p []byte, extensions ...ChunkExtension) (int, error) {
 	if w.state != WriterStateBody {
 		return 0, fmt.Errorf("Error: cannot write body on state %d", w.state)
 	}
-
-	nTotal := 0
-	n, err := fmt.Fprintf(w.writer, "%x\r\n", len(p))
-	if err != nil {
-		return 0, err
+	if w.strict && !w.chunked {
+		return 0, fmt.Errorf("Error: chunked body without Transfer-Encoding: chunked")
 	}
-	nTotal += n
-
-	n, err = w.writer.Write(p)
-	if err != nil {
-		return 0, nil
+	if w.discardBody {
+		return len(p), nil
 	}
-	nTotal += n
-
-	n, err = w.writer.Write([]byte("\r\n"))
-	if err != nil {
-		return 0, nil
+	if err := w.commitHeaders(); err != nil {
+		return 0, err
 	}
-	nTotal += n
 
-	return nTotal, nil
+	return w.writeChunk(p, extensions...)
 }
 
 func (w *Writer) WriteChunkedBodyDone() (int, error) {
@@ -100,6 +432,12 @@ func (w *Writer) WriteChunkedBodyDone() (int, error) {
 	}
 
 	w.state = WriterStateTrailers
+	if w.discardBody {
+		return 0, nil
+	}
+	if err := w.commitHeaders(); err != nil {
+		return 0, err
+	}
 	return w.writer.Write([]byte("0\r\n"))
 }
 
@@ -107,10 +445,20 @@ func (w *Writer) WriteTrailers(h headers.Headers) error {
 	if w.state != WriterStateTrailers {
 		return fmt.Errorf("Error: cannot write trailers on state %d", w.state)
 	}
+	if w.strict {
+		for k := range h {
+			if !slices.Contains(w.announcedTrailers, strings.ToLower(k)) {
+				return fmt.Errorf("Error: trailer %q not announced in Trailer header", k)
+			}
+		}
+	}
+	w.trailersDone = true
+	if w.discardBody {
+		return nil
+	}
 
 	for k, v := range h {
-		_, err := fmt.Fprintf(w.writer, "%s: %s\r\n", k, v)
-		if err != nil {
+		if err := w.writeField(k, v); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,211 @@
+package response
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscardBody(t *testing.T) {
+	// Test: Declared Content-Length is kept and the body dropped
+	var buff bytes.Buffer
+	w := NewResponseWriter(&buff)
+	w.DiscardBody()
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(GetDefaultHeaders(5)))
+	n, err := w.WriteBody([]byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	require.NoError(t, w.Close())
+	assert.Contains(t, buff.String(), "content-length: 5\r\n")
+	assert.True(t, strings.HasSuffix(buff.String(), "\r\n\r\n"))
+
+	// Test: Missing Content-Length is computed from the discarded body
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	w.DiscardBody()
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	h := GetDefaultHeaders(0)
+	h.Delete("Content-Length")
+	require.NoError(t, w.WriteHeaders(h))
+	w.WriteBody([]byte("hello "))
+	w.WriteBody([]byte("world"))
+	assert.Equal(t, "HTTP/1.1 200 OK\r\n", buff.String())
+	require.NoError(t, w.Close())
+	assert.Contains(t, buff.String(), "content-length: 11\r\n")
+	assert.True(t, strings.HasSuffix(buff.String(), "\r\n\r\n"))
+
+	// Test: Chunked body and trailers are dropped
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	w.DiscardBody()
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	h = GetDefaultHeaders(0)
+	h.Delete("Content-Length")
+	h.Set("Transfer-Encoding", "chunked")
+	require.NoError(t, w.WriteHeaders(h))
+	w.WriteChunkedBody([]byte("hello"))
+	w.WriteChunkedBodyDone()
+	require.NoError(t, w.WriteTrailers(nil))
+	require.NoError(t, w.Close())
+	assert.Contains(t, buff.String(), "transfer-encoding: chunked\r\n")
+	assert.True(t, strings.HasSuffix(buff.String(), "\r\n\r\n"))
+	assert.NotContains(t, buff.String(), "hello")
+}
+
+func TestSetCookieLines(t *testing.T) {
+	// Test: Each Set-Cookie value is written on its own line
+	var buff bytes.Buffer
+	w := NewResponseWriter(&buff)
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	h := GetDefaultHeaders(0)
+	h.Add("Set-Cookie", "a=1; Expires=Wed, 21 Oct 2026 07:28:00 GMT")
+	h.Add("Set-Cookie", "b=2")
+	require.NoError(t, w.WriteHeaders(h))
+	assert.Contains(t, buff.String(), "set-cookie: a=1; Expires=Wed, 21 Oct 2026 07:28:00 GMT\r\n")
+	assert.Contains(t, buff.String(), "set-cookie: b=2\r\n")
+
+	// Test: Values with CR or LF are rejected
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	h = GetDefaultHeaders(0)
+	h.Set("X-A", "foo\r\nEvil: 1")
+	require.Error(t, w.WriteHeaders(h))
+	assert.NotContains(t, buff.String(), "Evil")
+}
+
+func TestAutomaticHeaders(t *testing.T) {
+	// Test: Date and Server headers are added
+	var buff bytes.Buffer
+	w := NewResponseWriter(&buff)
+	w.SetServerHeader("learn-http-go")
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(GetDefaultHeaders(0)))
+	require.NoError(t, w.Close())
+	assert.Regexp(t, `date: [A-Z][a-z]{2}, \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2} GMT\r\n`, buff.String())
+	assert.Contains(t, buff.String(), "server: learn-http-go\r\n")
+
+	// Test: Handler supplied Date is kept
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	h := GetDefaultHeaders(0)
+	h.Set("Date", "Thu, 02 Jan 2025 03:04:05 GMT")
+	require.NoError(t, w.WriteHeaders(h))
+	assert.Contains(t, buff.String(), "date: Thu, 02 Jan 2025 03:04:05 GMT\r\n")
+	assert.NotContains(t, buff.String(), "server:")
+
+	// Test: Content-Length is inferred for a small undeclared body
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(nil))
+	w.WriteBody([]byte("hello "))
+	w.WriteBody([]byte("world"))
+	require.NoError(t, w.Close())
+	assert.Contains(t, buff.String(), "content-length: 11\r\n")
+	assert.True(t, strings.HasSuffix(buff.String(), "\r\n\r\nhello world"))
+
+	// Test: Large undeclared body is streamed chunked
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(nil))
+	large := strings.Repeat("a", inferLengthLimit+1)
+	w.WriteBody([]byte(large))
+	w.WriteBody([]byte("b"))
+	require.NoError(t, w.Close())
+	assert.NotContains(t, buff.String(), "content-length")
+	assert.Contains(t, buff.String(), "transfer-encoding: chunked\r\n")
+	assert.True(t, strings.HasSuffix(buff.String(), "\r\n\r\n1001\r\n"+large+"\r\n1\r\nb\r\n0\r\n\r\n"))
+
+	// Test: Flush before Close commits held headers as chunked
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(nil))
+	w.WriteBody([]byte("hello"))
+	require.NoError(t, w.Flush())
+	w.WriteBody([]byte("world"))
+	require.NoError(t, w.Close())
+	assert.Contains(t, buff.String(), "transfer-encoding: chunked\r\n")
+	assert.True(t, strings.HasSuffix(buff.String(), "\r\n\r\n5\r\nhello\r\n5\r\nworld\r\n0\r\n\r\n"))
+
+	// Test: No Date on informational responses
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	require.NoError(t, w.WriteStatusLine(StatusSwitchingProtocols))
+	require.NoError(t, w.WriteHeaders(nil))
+	assert.Equal(t, "HTTP/1.1 101 Switching Protocols\r\n\r\n", buff.String())
+}
+
+func TestStrictFraming(t *testing.T) {
+	// Test: Writing past Content-Length fails
+	var buff bytes.Buffer
+	w := NewResponseWriter(&buff)
+	w.StrictFraming()
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(GetDefaultHeaders(5)))
+	_, err := w.WriteBody([]byte("hello"))
+	require.NoError(t, err)
+	_, err = w.WriteBody([]byte("!"))
+	require.Error(t, err)
+	require.NoError(t, w.Close())
+	assert.True(t, strings.HasSuffix(buff.String(), "\r\n\r\nhello"))
+
+	// Test: Body shorter than Content-Length fails on Close
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	w.StrictFraming()
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(GetDefaultHeaders(10)))
+	w.WriteBody([]byte("hello"))
+	require.Error(t, w.Close())
+
+	// Test: Body without declared length is chunked
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	w.StrictFraming()
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(nil))
+	w.WriteBody([]byte("hello"))
+	w.WriteBody([]byte(" world"))
+	require.NoError(t, w.Close())
+	assert.Contains(t, buff.String(), "transfer-encoding: chunked\r\n")
+	assert.True(t, strings.HasSuffix(buff.String(), "\r\n\r\n5\r\nhello\r\n6\r\n world\r\n0\r\n\r\n"))
+
+	// Test: Chunked writes need Transfer-Encoding: chunked
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	w.StrictFraming()
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(GetDefaultHeaders(5)))
+	_, err = w.WriteChunkedBody([]byte("hello"))
+	require.Error(t, err)
+
+	// Test: Trailers must be announced
+	buff.Reset()
+	w = NewResponseWriter(&buff)
+	w.StrictFraming()
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	h := GetDefaultHeaders(0)
+	h.Delete("Content-Length")
+	h.Set("Transfer-Encoding", "chunked")
+	h.Set("Trailer", "X-Content-SHA256")
+	require.NoError(t, w.WriteHeaders(h))
+	_, err = w.WriteChunkedBody([]byte("hello"))
+	require.NoError(t, err)
+	_, err = w.WriteChunkedBodyDone()
+	require.NoError(t, err)
+	trailers := GetDefaultHeaders(0)
+	require.Error(t, w.WriteTrailers(trailers))
+	trailers = headers.Headers{"x-content-sha256": {"abc"}}
+	require.NoError(t, w.WriteTrailers(trailers))
+	require.NoError(t, w.Close())
+	assert.True(t, strings.HasSuffix(buff.String(), "0\r\nx-content-sha256: abc\r\n\r\n"))
+}
@@ -0,0 +1,123 @@
+package router
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/Quak1/learn-http-go/internal/problem"
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/Quak1/learn-http-go/internal/server"
+)
+
+type route struct {
+	method  string
+	pattern string
+	handler server.Handler
+}
+
+// Router dispatches on method and path. Patterns ending in "/" match every
+// path under them, the longest one winning, other patterns match exactly. An
+// empty method matches any method and HEAD falls back to GET routes.
+type Router struct {
+	routes []route
+}
+
+func New() *Router {
+	return &Router{}
+}
+
+func (r *Router) Add(method, pattern string, handler server.Handler) {
+	r.routes = append(r.routes, route{
+		method:  method,
+		pattern: pattern,
+		handler: handler,
+	})
+}
+
+func (r *Router) Get(pattern string, handler server.Handler) {
+	r.Add("GET", pattern, handler)
+}
+
+func (r *Router) Post(pattern string, handler server.Handler) {
+	r.Add("POST", pattern, handler)
+}
+
+func (r *Router) Handle(w *response.Writer, req *request.Request) {
+	candidates := r.match(Path(req.RequestLine.RequestTarget))
+	if len(candidates) == 0 {
+		problem.Write(w, req, problem.New(response.StatusNotFound, ""))
+		return
+	}
+
+	req.Pattern = candidates[0].pattern
+	method := req.RequestLine.Method
+	var fallback server.Handler
+	allowed := []string{}
+	for _, rt := range candidates {
+		switch {
+		case rt.method == "" || rt.method == method:
+			rt.handler(w, req)
+			return
+		case method == "HEAD" && rt.method == "GET" && fallback == nil:
+			fallback = rt.handler
+		}
+
+		allowed = append(allowed, rt.method)
+		if rt.method == "GET" {
+			allowed = append(allowed, "HEAD")
+		}
+	}
+
+	if fallback != nil {
+		fallback(w, req)
+		return
+	}
+
+	slices.Sort(allowed)
+	p := problem.New(response.StatusMethodNotAllowed, "")
+	p.Headers = headers.NewHeaders()
+	p.Headers.Set("Allow", strings.Join(slices.Compact(allowed), ", "))
+	problem.Write(w, req, p)
+}
+
+// match returns the routes registered for the most specific pattern that
+// matches path.
+func (r *Router) match(path string) []route {
+	best := ""
+	found := false
+	for _, rt := range r.routes {
+		if !patternMatches(rt.pattern, path) {
+			continue
+		}
+		if !found || len(rt.pattern) > len(best) {
+			best = rt.pattern
+			found = true
+		}
+	}
+
+	var routes []route
+	if !found {
+		return routes
+	}
+	for _, rt := range r.routes {
+		if rt.pattern == best {
+			routes = append(routes, rt)
+		}
+	}
+	return routes
+}
+
+func patternMatches(pattern, path string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(path, pattern)
+	}
+	return pattern == path
+}
+
+// Path returns the path part of a request target, without the query.
+func Path(target string) string {
+	path, _, _ := strings.Cut(target, "?")
+	return path
+}
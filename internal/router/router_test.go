@@ -0,0 +1,90 @@
+package router
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func named(name string) func(w *response.Writer, req *request.Request) {
+	return func(w *response.Writer, req *request.Request) {
+		w.WriteStatusLine(response.StatusOK)
+		w.WriteHeaders(response.GetDefaultHeaders(len(name)))
+		w.WriteBody([]byte(name))
+	}
+}
+
+func dispatch(t *testing.T, r *Router, raw string) string {
+	req, err := request.RequestFromReader(strings.NewReader(raw))
+	require.NoError(t, err)
+
+	var buff bytes.Buffer
+	w := response.NewResponseWriter(&buff)
+	if req.RequestLine.Method == "HEAD" {
+		w.DiscardBody()
+	}
+	r.Handle(w, req)
+	w.Close()
+	return buff.String()
+}
+
+func TestRouter(t *testing.T) {
+	r := New()
+	r.Get("/coffee", named("get coffee"))
+	r.Post("/coffee", named("post coffee"))
+	r.Get("/static/", named("static"))
+	r.Get("/static/special", named("special"))
+	r.Add("", "/any", named("any"))
+
+	// Test: Exact match by method
+	assert.True(t, strings.HasSuffix(dispatch(t, r, "GET /coffee HTTP/1.1\r\n\r\n"), "get coffee"))
+	assert.True(t, strings.HasSuffix(dispatch(t, r, "POST /coffee HTTP/1.1\r\n\r\n"), "post coffee"))
+
+	// Test: Query is ignored when matching
+	assert.True(t, strings.HasSuffix(dispatch(t, r, "GET /coffee?size=large HTTP/1.1\r\n\r\n"), "get coffee"))
+
+	// Test: Subtree pattern and longest match
+	assert.True(t, strings.HasSuffix(dispatch(t, r, "GET /static/css/site.css HTTP/1.1\r\n\r\n"), "static"))
+	assert.True(t, strings.HasSuffix(dispatch(t, r, "GET /static/special HTTP/1.1\r\n\r\n"), "special"))
+
+	// Test: Any method route
+	assert.True(t, strings.HasSuffix(dispatch(t, r, "DELETE /any HTTP/1.1\r\n\r\n"), "any"))
+
+	// Test: HEAD derived from GET keeps headers and drops the body
+	out := dispatch(t, r, "HEAD /coffee HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n"))
+	assert.Contains(t, out, "content-length: 10\r\n")
+	assert.True(t, strings.HasSuffix(out, "\r\n\r\n"))
+
+	// Test: Not found
+	out = dispatch(t, r, "GET /tea HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 404 Not Found\r\n"))
+	assert.Contains(t, out, "content-type: application/problem+json\r\n")
+
+	// Test: Method not allowed lists the allowed methods
+	out = dispatch(t, r, "PUT /coffee HTTP/1.1\r\n\r\n")
+	assert.True(t, strings.HasPrefix(out, "HTTP/1.1 405 Method Not Allowed\r\n"))
+	assert.Contains(t, out, "allow: GET, HEAD, POST\r\n")
+}
+
+func TestRouterPattern(t *testing.T) {
+	r := New()
+	r.Get("/static/", named("static"))
+
+	// Test: Matched pattern is recorded on the request
+	req, err := request.RequestFromReader(strings.NewReader("GET /static/css/site.css HTTP/1.1\r\n\r\n"))
+	require.NoError(t, err)
+	r.Handle(response.NewResponseWriter(&bytes.Buffer{}), req)
+	assert.Equal(t, "/static/", req.Pattern)
+
+	// Test: Unmatched requests keep an empty pattern
+	req, err = request.RequestFromReader(strings.NewReader("GET /other HTTP/1.1\r\n\r\n"))
+	require.NoError(t, err)
+	r.Handle(response.NewResponseWriter(&bytes.Buffer{}), req)
+	assert.Equal(t, "", req.Pattern)
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation.
+const listenFDsStart = 3
+
+// ServeListener serves connections accepted from ln until the server is
+// closed.
+func ServeListener(ln net.Listener, handler Handler) *Server {
+	return newServer(ln, handler)
+}
+
+// ServeAddr listens on addr, see Listen, and serves connections from it.
+func ServeAddr(addr string, handler Handler) (*Server, error) {
+	ln, err := Listen(addr)
+	if err != nil {
+		return nil, err
+	}
+	return newServer(ln, handler), nil
+}
+
+// Listen opens a listener for addr, either a TCP host:port such as
+// "127.0.0.1:8080", "[::1]:8080" or ":8080", a bare port, or a Unix socket
+// path prefixed with "unix:". A stale socket file left at the path is
+// removed first, a socket that still accepts connections is left alone.
+func Listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		if path == "" {
+			return nil, fmt.Errorf("Error: empty unix socket path")
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			conn, err := net.Dial("unix", path)
+			if err == nil {
+				conn.Close()
+				return nil, fmt.Errorf("Error: address %s already in use", path)
+			}
+			if !errors.Is(err, syscall.ECONNREFUSED) {
+				return nil, fmt.Errorf("Error: checking unix socket %s: %w", path, err)
+			}
+			os.Remove(path)
+		}
+		return net.Listen("unix", path)
+	}
+
+	if _, err := strconv.Atoi(addr); err == nil {
+		addr = ":" + addr
+	}
+	return net.Listen("tcp", addr)
+}
+
+// ListenersFromEnv returns the listening sockets passed by systemd socket
+// activation or by Restart, in order, or nil when there are none. The
+// variables are cleared so child processes don't inherit them.
+func ListenersFromEnv() ([]net.Listener, error) {
+	return listenersFromEnv(listenFDsStart)
+}
+
+func listenersFromEnv(start int) ([]net.Listener, error) {
+	count, err := strconv.Atoi(os.Getenv(restartFDsEnv))
+	if err == nil {
+		os.Unsetenv(restartFDsEnv)
+		return inheritedListeners(start, count, nil)
+	}
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err = strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	return inheritedListeners(start, count, names)
+}
+
+func inheritedListeners(start, count int, names []string) ([]net.Listener, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+
+	listeners := make([]net.Listener, 0, count)
+	for i := range count {
+		name := "LISTEN_FD_" + strconv.Itoa(start+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		f := os.NewFile(uintptr(start+i), name)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("Error: inherited descriptor %d (%s): %w", start+i, name, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ok(w *response.Writer, req *request.Request) {
+	w.WriteStatusLine(response.StatusOK)
+	w.WriteHeaders(response.GetDefaultHeaders(2))
+	w.WriteBody([]byte("ok"))
+}
+
+func roundTrip(t *testing.T, network, addr string) string {
+	conn, err := net.Dial(network, addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	require.NoError(t, err)
+	out, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestServeAddr(t *testing.T) {
+	// Test: IPv4 host and port
+	s, err := ServeAddr("127.0.0.1:0", ok)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(roundTrip(t, "tcp", s.Addr().String()), "ok"))
+	s.Close()
+
+	// Test: IPv6 host and port
+	s, err = ServeAddr("[::1]:0", ok)
+	if err == nil {
+		assert.True(t, strings.HasSuffix(roundTrip(t, "tcp", s.Addr().String()), "ok"))
+		s.Close()
+	}
+
+	// Test: Unix socket replacing a stale one
+	path := filepath.Join(t.TempDir(), "http.sock")
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	s, err = ServeAddr("unix:"+path, ok)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(roundTrip(t, "unix", path), "ok"))
+
+	// Test: Socket still in use is not replaced
+	_, err = ServeAddr("unix:"+path, ok)
+	require.Error(t, err)
+	assert.True(t, strings.HasSuffix(roundTrip(t, "unix", path), "ok"))
+	s.Close()
+
+	// Test: Regular files aren't removed
+	file := filepath.Join(t.TempDir(), "data")
+	require.NoError(t, os.WriteFile(file, []byte("keep"), 0o600))
+	_, err = ServeAddr("unix:"+file, ok)
+	require.Error(t, err)
+	_, err = os.Stat(file)
+	require.NoError(t, err)
+
+	// Test: Invalid addresses
+	_, err = ServeAddr("unix:", ok)
+	require.Error(t, err)
+	_, err = ServeAddr("127.0.0.1:http-alt-nope", ok)
+	require.Error(t, err)
+}
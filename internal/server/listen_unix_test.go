@@ -0,0 +1,53 @@
+//go:build unix
+
+package server
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenersFromEnv(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	require.NoError(t, err)
+	// the descriptor is owned by the listener built from it
+	fd, err := syscall.Dup(int(f.Fd()))
+	require.NoError(t, err)
+	f.Close()
+
+	// Test: Not activated
+	t.Setenv("LISTEN_PID", "")
+	listeners, err := listenersFromEnv(fd)
+	require.NoError(t, err)
+	assert.Nil(t, listeners)
+
+	// Test: Descriptors meant for another process
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+	listeners, err = listenersFromEnv(fd)
+	require.NoError(t, err)
+	assert.Nil(t, listeners)
+
+	// Test: Inherited listener is served and the variables cleared
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "http")
+	listeners, err = listenersFromEnv(fd)
+	require.NoError(t, err)
+	require.Len(t, listeners, 1)
+	assert.Empty(t, os.Getenv("LISTEN_FDS"))
+
+	s := ServeListener(listeners[0], ok)
+	defer s.Close()
+	assert.True(t, strings.HasSuffix(roundTrip(t, "tcp", ln.Addr().String()), "ok"))
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/metrics"
+	"github.com/Quak1/learn-http-go/internal/request"
+)
+
+// Metrics instruments a Server, see SetMetrics.
+type Metrics struct {
+	activeConnections   *metrics.Gauge
+	acceptedConnections *metrics.Counter
+	requests            *metrics.Counter
+	duration            *metrics.Histogram
+	receivedBytes       *metrics.Counter
+	sentBytes           *metrics.Counter
+	parseErrors         *metrics.Counter
+}
+
+func NewMetrics(r *metrics.Registry) *Metrics {
+	return &Metrics{
+		activeConnections:   r.NewGauge("http_connections_active", "Open client connections, hijacked ones included."),
+		acceptedConnections: r.NewCounter("http_connections_accepted_total", "Client connections accepted."),
+		requests:            r.NewCounter("http_requests_total", "Requests served.", "method", "route", "status"),
+		duration:            r.NewHistogram("http_request_duration_seconds", "Time spent handling requests.", metrics.DefaultBuckets, "method", "route"),
+		receivedBytes:       r.NewCounter("http_received_bytes_total", "Bytes read from client connections."),
+		sentBytes:           r.NewCounter("http_sent_bytes_total", "Bytes written to client connections."),
+		parseErrors:         r.NewCounter("http_request_parse_errors_total", "Requests that couldn't be read.", "kind"),
+	}
+}
+
+var knownMethods = []string{"GET", "HEAD", "POST", "PUT", "DELETE", "CONNECT", "OPTIONS", "TRACE", "PATCH"}
+
+func (m *Metrics) observeRequest(req *request.Request, status int, elapsed time.Duration) {
+	method := "OTHER"
+	for _, known := range knownMethods {
+		if req.RequestLine.Method == known {
+			method = known
+		}
+	}
+	route := req.Pattern
+	if route == "" {
+		route = "none"
+	}
+
+	m.requests.Inc(method, route, strconv.Itoa(status))
+	m.duration.Observe(elapsed.Seconds(), method, route)
+}
+
+func (m *Metrics) observeParseError(err error) {
+	var netErr net.Error
+	kind := "read"
+	switch {
+	case errors.Is(err, request.ErrMalformedRequest):
+		kind = "malformed"
+	case errors.Is(err, request.ErrIncompleteRequest):
+		kind = "incomplete"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		kind = "timeout"
+	}
+	m.parseErrors.Inc(kind)
+}
+
+// meteredConn counts the bytes going through a connection and marks it
+// inactive once closed.
+type meteredConn struct {
+	net.Conn
+	m         *Metrics
+	closeOnce sync.Once
+}
+
+func (m *Metrics) wrapConn(conn net.Conn) net.Conn {
+	m.acceptedConnections.Inc()
+	m.activeConnections.Inc()
+	return &meteredConn{Conn: conn, m: m}
+}
+
+func (c *meteredConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func (c *meteredConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.m.receivedBytes.Add(float64(n))
+	return n, err
+}
+
+func (c *meteredConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.m.sentBytes.Add(float64(n))
+	return n, err
+}
+
+func (c *meteredConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.m.activeConnections.Dec()
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+const (
+	restartFDsEnv = "HTTPSERVER_RESTART_FDS"
+	readyFDEnv    = "HTTPSERVER_READY_FD"
+)
+
+// Restart starts a new copy of the running executable with the same
+// arguments, handing it the listeners as inherited file descriptors which it
+// picks up with ListenersFromEnv. It returns once the new process calls
+// NotifyReady, after which the caller should Shutdown its servers. If the new
+// process exits or isn't ready within timeout it is killed and an error
+// returned, leaving the current process in charge.
+func Restart(timeout time.Duration, listeners ...net.Listener) (*os.Process, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*os.File, 0, len(listeners)+1)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, ln := range listeners {
+		filer, ok := ln.(interface{ File() (*os.File, error) })
+		if !ok {
+			return nil, fmt.Errorf("Error: listener %T can't be handed over", ln)
+		}
+		f, err := filer.File()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	ready, readyWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer ready.Close()
+	files = append(files, readyWriter)
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		restartFDsEnv+"="+strconv.Itoa(len(listeners)),
+		readyFDEnv+"="+strconv.Itoa(listenFDsStart+len(listeners)),
+	)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	readyWriter.Close()
+	files = files[:len(files)-1]
+	go cmd.Wait()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(ready, make([]byte, 1))
+		result <- err
+	}()
+
+	select {
+	case err = <-result:
+	case <-time.After(timeout):
+		err = errors.New("Error: timed out")
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("Error: new process didn't become ready: %w", err)
+	}
+
+	// the old process closing its listeners must not remove the socket files
+	for _, ln := range listeners {
+		if unix, ok := ln.(*net.UnixListener); ok {
+			unix.SetUnlinkOnClose(false)
+		}
+	}
+	return cmd.Process, nil
+}
+
+// NotifyReady tells the process that started this one with Restart that it
+// is serving. It does nothing when the process wasn't started by Restart.
+func NotifyReady() error {
+	fd, err := strconv.Atoi(os.Getenv(readyFDEnv))
+	if err != nil {
+		return nil
+	}
+	os.Unsetenv(readyFDEnv)
+
+	f := os.NewFile(uintptr(fd), "ready")
+	defer f.Close()
+	_, err = f.Write([]byte{1})
+	return err
+}
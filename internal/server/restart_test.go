@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s, err := ServeAddr("127.0.0.1:0", func(w *response.Writer, req *request.Request) {
+		close(started)
+		<-release
+		ok(w, req)
+	})
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\n\r\n"))
+	require.NoError(t, err)
+	<-started
+
+	// Test: Shutdown waits for requests in progress
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, s.Shutdown(ctx), context.DeadlineExceeded)
+
+	// Test: New connections are refused
+	_, err = net.Dial("tcp", s.Addr().String())
+	require.Error(t, err)
+
+	// Test: The request in progress completes
+	close(release)
+	require.NoError(t, s.Shutdown(context.Background()))
+	out, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(out), "ok"))
+}
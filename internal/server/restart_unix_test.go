@@ -0,0 +1,35 @@
+//go:build unix
+
+package server
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyReady(t *testing.T) {
+	// Test: Not started by Restart
+	t.Setenv(readyFDEnv, "")
+	require.NoError(t, NotifyReady())
+
+	// Test: Writes to the inherited descriptor
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	fd, err := syscall.Dup(int(w.Fd()))
+	require.NoError(t, err)
+	w.Close()
+	t.Setenv(readyFDEnv, strconv.Itoa(fd))
+	require.NoError(t, NotifyReady())
+	assert.Empty(t, os.Getenv(readyFDEnv))
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, b)
+}
@@ -1,12 +1,16 @@
 package server
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/Quak1/learn-http-go/internal/problem"
 	"github.com/Quak1/learn-http-go/internal/request"
 	"github.com/Quak1/learn-http-go/internal/response"
 )
@@ -17,30 +21,81 @@ type Server struct {
 	handler      Handler
 	listener     net.Listener
 	serverClosed atomic.Bool
+	serverHeader atomic.Value
+	strict       atomic.Bool
+	metrics      atomic.Pointer[Metrics]
+	acceptDone   chan struct{}
+	conns        sync.WaitGroup
 }
 
 func Serve(port int, handler Handler) (*Server, error) {
-	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
-	if err != nil {
-		return nil, err
-	}
+	return ServeAddr(fmt.Sprintf(":%d", port), handler)
+}
 
-	s := Server{
-		listener: ln,
-		handler:  handler,
+func newServer(ln net.Listener, handler Handler) *Server {
+	s := &Server{
+		listener:   ln,
+		handler:    handler,
+		acceptDone: make(chan struct{}),
 	}
 
 	go s.listen()
 
-	return &s, nil
+	return s
+}
+
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// SetServerHeader sets the Server header sent with every response, empty to
+// send none.
+func (s *Server) SetServerHeader(value string) {
+	s.serverHeader.Store(value)
+}
+
+// SetStrictFraming turns on response.Writer.StrictFraming for every request
+// from now on. It is off by default since handlers written against the
+// lenient writer may rely on it fixing up their framing.
+func (s *Server) SetStrictFraming(strict bool) {
+	s.strict.Store(strict)
+}
+
+// SetMetrics records connection and request metrics in m from now on, nil to
+// stop recording.
+func (s *Server) SetMetrics(m *Metrics) {
+	s.metrics.Store(m)
 }
 
 func (s *Server) Close() error {
-	s.serverClosed.Store(true)
+	if s.serverClosed.Swap(true) {
+		return nil
+	}
 	return s.listener.Close()
 }
 
+// Shutdown stops accepting connections and waits for the requests in
+// progress to finish, or for ctx to be done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.Close()
+	<-s.acceptDone
+
+	done := make(chan struct{})
+	go func() {
+		s.conns.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (s *Server) listen() {
+	defer close(s.acceptDone)
 	for {
 		conn, err := s.listener.Accept()
 		if err != nil {
@@ -52,22 +107,57 @@ func (s *Server) listen() {
 			continue
 		}
 
-		go s.handle(conn)
+		s.conns.Add(1)
+		go func() {
+			defer s.conns.Done()
+			s.handle(conn)
+		}()
 	}
 }
 
 func (s *Server) handle(conn net.Conn) {
-	defer conn.Close()
+	m := s.metrics.Load()
+	if m != nil {
+		conn = m.wrapConn(conn)
+	}
 
 	req, err := request.RequestFromReader(conn)
 	if err != nil {
+		if m != nil {
+			m.observeParseError(err)
+		}
+		if errors.Is(err, request.ErrMalformedRequest) {
+			log.Println(err)
+			writer := response.NewConnResponseWriter(conn, nil)
+			problem.Write(writer, nil, problem.New(response.StatusBadRequest, "The request could not be parsed."))
+			writer.Flush()
+		}
+		conn.Close()
 		return
 	}
 
-	var buff bytes.Buffer
-	writer := response.NewResponseWriter(&buff)
+	req.RemoteAddr = conn.RemoteAddr().String()
+	req.TLS = tlsState(conn)
+
+	writer := response.NewConnResponseWriter(conn, req.Buffered())
+	if value, ok := s.serverHeader.Load().(string); ok {
+		writer.SetServerHeader(value)
+	}
+	if s.strict.Load() {
+		writer.StrictFraming()
+	}
+	if req.RequestLine.Method == "HEAD" {
+		writer.DiscardBody()
+	}
+	start := time.Now()
 	s.handler(writer, req)
+	if !writer.Hijacked() {
+		writer.Close()
+		writer.Flush()
+		conn.Close()
+	}
 
-	b := buff.Bytes()
-	conn.Write(b)
+	if m != nil {
+		m.observeRequest(req, int(writer.Status()), time.Since(start))
+	}
 }
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictFraming(t *testing.T) {
+	overrun := func(w *response.Writer, req *request.Request) {
+		w.WriteStatusLine(response.StatusOK)
+		w.WriteHeaders(response.GetDefaultHeaders(2))
+		w.WriteBody([]byte("okay"))
+	}
+
+	// Test: Lenient by default
+	s, err := ServeAddr("127.0.0.1:0", overrun)
+	require.NoError(t, err)
+	defer s.Close()
+	assert.True(t, strings.HasSuffix(roundTrip(t, "tcp", s.Addr().String()), "\r\n\r\nokay"))
+
+	// Test: Writes past Content-Length are refused when strict
+	s.SetStrictFraming(true)
+	assert.True(t, strings.HasSuffix(roundTrip(t, "tcp", s.Addr().String()), "\r\n\r\n"))
+}
+
+func TestMalformedRequest(t *testing.T) {
+	s, err := ServeAddr("127.0.0.1:0", ok)
+	require.NoError(t, err)
+	defer s.Close()
+
+	// Test: 400 without the parser error in the detail
+	conn, err := net.Dial("tcp", s.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET /coffee\r\n\r\n"))
+	require.NoError(t, err)
+	out, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(out), "HTTP/1.1 400 Bad Request\r\n"))
+	assert.Contains(t, string(out), "The request could not be parsed.")
+	assert.NotContains(t, string(out), "Error:")
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ServeTLS serves HTTP/1.1 over TLS, see NewTLSListener.
+func ServeTLS(port int, handler Handler, config *tls.Config) (*Server, error) {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, err
+	}
+
+	return newServer(NewTLSListener(ln, config), handler), nil
+}
+
+// ServeTLSFiles serves HTTP/1.1 over TLS with the certificate in certFile and
+// keyFile. The returned CertReloader picks up renewed files, see Reload and
+// ReloadOnSIGHUP.
+func ServeTLSFiles(port int, handler Handler, certFile, keyFile string) (*Server, *CertReloader, error) {
+	certs, err := NewCertReloader(CertFiles{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s, err := ServeTLS(port, handler, certs.TLSConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, certs, nil
+}
+
+// NewTLSListener wraps ln to terminate TLS. ALPN advertises http/1.1 unless
+// config sets its own protocols.
+func NewTLSListener(ln net.Listener, config *tls.Config) net.Listener {
+	config = config.Clone()
+	if len(config.NextProtos) == 0 {
+		config.NextProtos = []string{"http/1.1"}
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+	return tls.NewListener(ln, config)
+}
+
+// tlsState returns the state of the TLS connection under any wrappers, or
+// nil for plain connections.
+func tlsState(conn net.Conn) *tls.ConnectionState {
+	for {
+		switch c := conn.(type) {
+		case *tls.Conn:
+			state := c.ConnectionState()
+			return &state
+		case interface{ NetConn() net.Conn }:
+			conn = c.NetConn()
+		default:
+			return nil
+		}
+	}
+}
+
+type CertFiles struct {
+	CertFile string
+	KeyFile  string
+}
+
+// CertReloader serves certificates loaded from disk, choosing one by SNI
+// server name. Reload swaps them in place, so new handshakes use the new
+// certificates while open connections keep going.
+type CertReloader struct {
+	files []CertFiles
+
+	mu    sync.RWMutex
+	certs []*tls.Certificate
+}
+
+func NewCertReloader(files ...CertFiles) (*CertReloader, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("Error: no certificates given")
+	}
+
+	r := &CertReloader{files: files}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads every certificate again. On error the certificates in use
+// are kept.
+func (r *CertReloader) Reload() error {
+	certs := make([]*tls.Certificate, 0, len(r.files))
+	for _, f := range r.files {
+		cert, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+		if err != nil {
+			return fmt.Errorf("Error: loading %s: %w", f.CertFile, err)
+		}
+		certs = append(certs, &cert)
+	}
+
+	r.mu.Lock()
+	r.certs = certs
+	r.mu.Unlock()
+	return nil
+}
+
+// ReloadOnSIGHUP reloads the certificates every time the process receives
+// SIGHUP until stop is called.
+func (r *CertReloader) ReloadOnSIGHUP() (stop func()) {
+	sigChan := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigChan, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-sigChan:
+				if err := r.Reload(); err != nil {
+					log.Println(err)
+					continue
+				}
+				log.Println("Reloaded TLS certificates")
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigChan)
+			close(done)
+		})
+	}
+}
+
+// GetCertificate picks the first certificate valid for the requested server
+// name, falling back to the first one loaded.
+func (r *CertReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if hello.ServerName != "" {
+		for _, cert := range r.certs {
+			if cert.Leaf != nil && cert.Leaf.VerifyHostname(hello.ServerName) == nil {
+				return cert, nil
+			}
+		}
+	}
+	return r.certs[0], nil
+}
+
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"http/1.1"},
+		GetCertificate: r.GetCertificate,
+	}
+}
+
+// SelfSignedCertificate generates a throwaway certificate for the given host
+// names and IP addresses, meant for local development only.
+func SelfSignedCertificate(hosts ...string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"learn-http-go development"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	if len(hosts) > 0 {
+		template.Subject.CommonName = hosts[0]
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
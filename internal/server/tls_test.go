@@ -0,0 +1,139 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCert(t *testing.T, dir, name string, hosts ...string) CertFiles {
+	cert, err := SelfSignedCertificate(hosts...)
+	require.NoError(t, err)
+
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.NoError(t, err)
+
+	files := CertFiles{
+		CertFile: filepath.Join(dir, name+".crt"),
+		KeyFile:  filepath.Join(dir, name+".key"),
+	}
+	require.NoError(t, os.WriteFile(files.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600))
+	require.NoError(t, os.WriteFile(files.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0o600))
+	return files
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	api := writeCert(t, dir, "api", "api.example.com")
+	wildcard := writeCert(t, dir, "www", "*.example.org")
+
+	r, err := NewCertReloader(api, wildcard)
+	require.NoError(t, err)
+
+	// Test: SNI picks the matching certificate
+	cert, err := r.GetCertificate(&tls.ClientHelloInfo{ServerName: "api.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api.example.com"}, cert.Leaf.DNSNames)
+
+	cert, err = r.GetCertificate(&tls.ClientHelloInfo{ServerName: "shop.example.org"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.example.org"}, cert.Leaf.DNSNames)
+
+	// Test: Unknown names fall back to the first certificate
+	cert, err = r.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.test"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api.example.com"}, cert.Leaf.DNSNames)
+
+	// Test: Reload picks up new files
+	writeCert(t, dir, "api", "api.example.com", "api2.example.com")
+	require.NoError(t, r.Reload())
+	cert, err = r.GetCertificate(&tls.ClientHelloInfo{ServerName: "api2.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api.example.com", "api2.example.com"}, cert.Leaf.DNSNames)
+
+	// Test: Failed reload keeps the current certificates
+	require.NoError(t, os.WriteFile(api.CertFile, []byte("garbage"), 0o600))
+	require.Error(t, r.Reload())
+	cert, err = r.GetCertificate(&tls.ClientHelloInfo{ServerName: "api2.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api.example.com", "api2.example.com"}, cert.Leaf.DNSNames)
+
+	// Test: Missing files
+	_, err = NewCertReloader(CertFiles{CertFile: filepath.Join(dir, "nope.crt"), KeyFile: filepath.Join(dir, "nope.key")})
+	require.Error(t, err)
+}
+
+func TestServeTLS(t *testing.T) {
+	cert, err := SelfSignedCertificate("localhost", "127.0.0.1")
+	require.NoError(t, err)
+
+	s, err := ServeTLS(0, func(w *response.Writer, req *request.Request) {
+		w.WriteStatusLine(response.StatusOK)
+		w.WriteHeaders(response.GetDefaultHeaders(6))
+		w.WriteBody([]byte("secure"))
+	}, &tls.Config{Certificates: []tls.Certificate{cert}})
+	require.NoError(t, err)
+	defer s.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert.Leaf)
+
+	// Test: Request over TLS with ALPN
+	conn, err := tls.Dial("tcp", s.Addr().String(), &tls.Config{
+		RootCAs:    roots,
+		ServerName: "localhost",
+		NextProtos: []string{"h2", "http/1.1"},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "http/1.1", conn.ConnectionState().NegotiatedProtocol)
+
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	require.NoError(t, err)
+	out, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(out), "HTTP/1.1 200 OK\r\n"))
+	assert.True(t, strings.HasSuffix(string(out), "secure"))
+}
+
+func TestServeTLSFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := writeCert(t, dir, "localhost", "localhost")
+
+	s, certs, err := ServeTLSFiles(0, func(w *response.Writer, req *request.Request) {
+		w.WriteStatusLine(response.StatusOK)
+		w.WriteHeaders(response.GetDefaultHeaders(0))
+	}, files.CertFile, files.KeyFile)
+	require.NoError(t, err)
+	defer s.Close()
+
+	leaf := func() *x509.Certificate {
+		conn, err := tls.Dial("tcp", s.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		require.NoError(t, err)
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0]
+	}
+
+	// Test: Certificate loaded from the files
+	first := leaf()
+	assert.Equal(t, []string{"localhost"}, first.DNSNames)
+
+	// Test: Reload serves the renewed certificate to new connections
+	writeCert(t, dir, "localhost", "localhost")
+	require.NoError(t, certs.Reload())
+	assert.NotEqual(t, first.SerialNumber, leaf().SerialNumber)
+
+	// Test: Missing files
+	_, _, err = ServeTLSFiles(0, nil, filepath.Join(dir, "nope.crt"), filepath.Join(dir, "nope.key"))
+	require.Error(t, err)
+}
@@ -0,0 +1,430 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+const (
+	CloseNormalClosure      = 1000
+	CloseGoingAway          = 1001
+	CloseProtocolError      = 1002
+	CloseUnsupportedData    = 1003
+	CloseNoStatusReceived   = 1005
+	CloseInvalidPayloadData = 1007
+	CloseMessageTooBig      = 1009
+	CloseInternalServerErr  = 1011
+)
+
+const maxControlPayload = 125
+
+// deflateTail is the empty stored block a sender strips from the end of each
+// compressed message, RFC 7692 section 7.2.1.
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Reason)
+}
+
+type frame struct {
+	fin     bool
+	rsv1    bool
+	opcode  byte
+	payload []byte
+}
+
+type Conn struct {
+	// FragmentSize splits outgoing data messages into frames of at most this
+	// many payload bytes, zero sends every message as a single frame.
+	FragmentSize int
+
+	conn           net.Conn
+	reader         io.Reader
+	isServer       bool
+	compress       bool
+	subprotocol    string
+	maxMessageSize int64
+
+	writeMu   sync.Mutex
+	closeSent bool
+}
+
+func newConn(conn net.Conn, r io.Reader, isServer, compress bool) *Conn {
+	return &Conn{
+		conn:           conn,
+		reader:         r,
+		isServer:       isServer,
+		compress:       compress,
+		maxMessageSize: defaultMaxMessageSize,
+	}
+}
+
+func (c *Conn) NetConn() net.Conn {
+	return c.conn
+}
+
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	messageType := 0
+	compressed := false
+	var message []byte
+
+	for {
+		f, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch f.opcode {
+		case PingMessage:
+			// Nothing but the close frame may follow ours, the peer's
+			// close reply is still worth reading.
+			if c.closing() {
+				continue
+			}
+			if err := c.writeFrame(PongMessage, false, true, f.payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			return 0, nil, c.handleClose(f.payload)
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "new message before previous one finished")
+			}
+			messageType = int(f.opcode)
+			compressed = f.rsv1
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "continuation without message")
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		message = append(message, f.payload...)
+		if int64(len(message)) > c.maxMessageSize {
+			return 0, nil, c.fail(CloseMessageTooBig, "message too big")
+		}
+		if !f.fin {
+			continue
+		}
+
+		if compressed {
+			message, err = c.decompress(message)
+			if err != nil {
+				return 0, nil, err
+			}
+		}
+		if messageType == TextMessage && !utf8.Valid(message) {
+			return 0, nil, c.fail(CloseInvalidPayloadData, "invalid UTF-8 in text message")
+		}
+
+		return messageType, message, nil
+	}
+}
+
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+	case PingMessage, PongMessage:
+		if len(data) > maxControlPayload {
+			return fmt.Errorf("Error: control frame payload too long")
+		}
+		return c.writeFrame(byte(messageType), false, true, data)
+	case CloseMessage:
+		return fmt.Errorf("Error: use WriteClose to send close frames")
+	default:
+		return fmt.Errorf("Error: unknown message type %d", messageType)
+	}
+
+	compressed := false
+	if c.compress {
+		var err error
+		data, err = compress(data)
+		if err != nil {
+			return err
+		}
+		compressed = true
+	}
+
+	opcode := byte(messageType)
+	for {
+		chunk := data
+		if c.FragmentSize > 0 && len(chunk) > c.FragmentSize {
+			chunk = data[:c.FragmentSize]
+		}
+		data = data[len(chunk):]
+
+		if err := c.writeFrame(opcode, compressed, len(data) == 0, chunk); err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			return nil
+		}
+
+		opcode = continuationFrame
+		compressed = false
+	}
+}
+
+func (c *Conn) Ping(data []byte) error {
+	return c.WriteMessage(PingMessage, data)
+}
+
+// WriteClose starts the closing handshake, the connection is closed once the
+// peer's close frame is read by ReadMessage.
+func (c *Conn) WriteClose(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > maxControlPayload {
+		return fmt.Errorf("Error: close reason too long")
+	}
+
+	return c.writeFrame(CloseMessage, false, true, payload)
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Conn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	switch {
+	case len(payload) == 1:
+		return c.fail(CloseProtocolError, "invalid close payload")
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Reason = string(payload[2:])
+		if !validCloseCode(closeErr.Code) || !utf8.Valid(payload[2:]) {
+			return c.fail(CloseProtocolError, "invalid close payload")
+		}
+	}
+
+	if !c.closing() {
+		var reply []byte
+		if closeErr.Code != CloseNoStatusReceived {
+			reply = binary.BigEndian.AppendUint16(nil, uint16(closeErr.Code))
+		}
+		c.writeFrame(CloseMessage, false, true, reply)
+	}
+
+	c.conn.Close()
+	return closeErr
+}
+
+// closing reports whether a close frame was already sent.
+func (c *Conn) closing() bool {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.closeSent
+}
+
+func (c *Conn) fail(code int, reason string) error {
+	c.WriteClose(code, reason)
+	c.conn.Close()
+	return &CloseError{Code: code, Reason: reason}
+}
+
+func (c *Conn) readFrame() (frame, error) {
+	var f frame
+
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return f, err
+	}
+
+	f.fin = header[0]&0x80 != 0
+	f.rsv1 = header[0]&0x40 != 0
+	f.opcode = header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+
+	if header[0]&0x30 != 0 {
+		return f, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	if f.rsv1 && (!c.compress || f.opcode == continuationFrame || f.opcode >= CloseMessage) {
+		return f, c.fail(CloseProtocolError, "unexpected compressed frame")
+	}
+	if masked != c.isServer {
+		return f, c.fail(CloseProtocolError, "invalid frame masking")
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return f, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return f, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if f.opcode >= CloseMessage && (!f.fin || length > maxControlPayload) {
+		return f, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if length > uint64(c.maxMessageSize) {
+		return f, c.fail(CloseMessageTooBig, "message too big")
+	}
+
+	var maskKey [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.reader, maskKey[:]); err != nil {
+			return f, err
+		}
+	}
+
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, f.payload); err != nil {
+		return f, err
+	}
+	if masked {
+		maskBytes(maskKey, f.payload)
+	}
+
+	return f, nil
+}
+
+func (c *Conn) writeFrame(opcode byte, rsv1, fin bool, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return fmt.Errorf("Error: close frame already sent")
+	}
+	if opcode == CloseMessage {
+		c.closeSent = true
+	}
+
+	var b0 byte = opcode
+	if fin {
+		b0 |= 0x80
+	}
+	if rsv1 {
+		b0 |= 0x40
+	}
+
+	buff := []byte{b0, 0}
+	length := len(payload)
+	switch {
+	case length < 126:
+		buff[1] = byte(length)
+	case length <= 0xffff:
+		buff[1] = 126
+		buff = binary.BigEndian.AppendUint16(buff, uint16(length))
+	default:
+		buff[1] = 127
+		buff = binary.BigEndian.AppendUint64(buff, uint64(length))
+	}
+
+	if c.isServer {
+		buff = append(buff, payload...)
+	} else {
+		var maskKey [4]byte
+		rand.Read(maskKey[:])
+		buff[1] |= 0x80
+		buff = append(buff, maskKey[:]...)
+
+		start := len(buff)
+		buff = append(buff, payload...)
+		maskBytes(maskKey, buff[start:])
+	}
+
+	_, err := c.conn.Write(buff)
+	return err
+}
+
+func (c *Conn) decompress(data []byte) ([]byte, error) {
+	r := flate.NewReader(io.MultiReader(bytes.NewReader(data), bytes.NewReader(deflateTail)))
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, c.maxMessageSize+1))
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, c.fail(CloseInvalidPayloadData, "invalid compressed data")
+	}
+	if int64(len(out)) > c.maxMessageSize {
+		return nil, c.fail(CloseMessageTooBig, "message too big")
+	}
+
+	return out, nil
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buff bytes.Buffer
+	w, err := flate.NewWriter(&buff, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buff.Bytes(), deflateTail), nil
+}
+
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i%4]
+	}
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003:
+		return true
+	case code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+func IsCloseError(err error, codes ...int) bool {
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		return false
+	}
+	for _, code := range codes {
+		if closeErr.Code == code {
+			return true
+		}
+	}
+	return len(codes) == 0
+}
@@ -0,0 +1,132 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/Quak1/learn-http-go/internal/headers"
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const defaultMaxMessageSize = 32 << 20
+
+type Upgrader struct {
+	Subprotocols      []string
+	EnableCompression bool
+	MaxMessageSize    int64
+}
+
+func (u *Upgrader) Upgrade(w *response.Writer, req *request.Request) (*Conn, error) {
+	if req.RequestLine.Method != "GET" {
+		return nil, rejectHandshake(w, response.StatusBadRequest, "websocket: method must be GET")
+	}
+	if !headerContainsToken(req.Headers, "Connection", "upgrade") {
+		return nil, rejectHandshake(w, response.StatusBadRequest, "websocket: missing Connection: upgrade")
+	}
+	if !headerContainsToken(req.Headers, "Upgrade", "websocket") {
+		return nil, rejectHandshake(w, response.StatusBadRequest, "websocket: missing Upgrade: websocket")
+	}
+	if req.Headers.Get("Sec-WebSocket-Version") != "13" {
+		return nil, rejectHandshake(w, response.StatusUpgradeRequired, "websocket: unsupported version")
+	}
+
+	key := req.Headers.Get("Sec-WebSocket-Key")
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(decoded) != 16 {
+		return nil, rejectHandshake(w, response.StatusBadRequest, "websocket: invalid Sec-WebSocket-Key")
+	}
+
+	h := headers.NewHeaders()
+	h.Set("Upgrade", "websocket")
+	h.Set("Connection", "Upgrade")
+	h.Set("Sec-WebSocket-Accept", AcceptKey(key))
+
+	protocol := u.selectSubprotocol(req.Headers)
+	if protocol != "" {
+		h.Set("Sec-WebSocket-Protocol", protocol)
+	}
+
+	compress := u.EnableCompression && headerContainsToken(req.Headers, "Sec-WebSocket-Extensions", "permessage-deflate")
+	if compress {
+		h.Set("Sec-WebSocket-Extensions", "permessage-deflate; server_no_context_takeover; client_no_context_takeover")
+	}
+
+	if err := w.WriteStatusLine(response.StatusSwitchingProtocols); err != nil {
+		return nil, err
+	}
+	if err := w.WriteHeaders(h); err != nil {
+		return nil, err
+	}
+
+	conn, buffered, err := w.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(io.MultiReader(bytes.NewReader(buffered), conn))
+	c := newConn(conn, r, true, compress)
+	c.subprotocol = protocol
+	if u.MaxMessageSize > 0 {
+		c.maxMessageSize = u.MaxMessageSize
+	}
+
+	return c, nil
+}
+
+func (u *Upgrader) selectSubprotocol(h headers.Headers) string {
+	for _, protocol := range splitList(h.Get("Sec-WebSocket-Protocol")) {
+		if slices.Contains(u.Subprotocols, protocol) {
+			return protocol
+		}
+	}
+	return ""
+}
+
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func rejectHandshake(w *response.Writer, status response.StatusCode, reason string) error {
+	body := reason + "\n"
+
+	w.WriteStatusLine(status)
+	h := response.GetDefaultHeaders(len(body))
+	if status == response.StatusUpgradeRequired {
+		h.Set("Sec-WebSocket-Version", "13")
+	}
+	w.WriteHeaders(h)
+	w.WriteBody([]byte(body))
+
+	return fmt.Errorf("Error: %s", reason)
+}
+
+func headerContainsToken(h headers.Headers, key, token string) bool {
+	for _, v := range splitList(h.Get(key)) {
+		name, _, _ := strings.Cut(v, ";")
+		if strings.EqualFold(strings.TrimSpace(name), token) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
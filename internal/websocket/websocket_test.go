@@ -0,0 +1,195 @@
+package websocket
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/Quak1/learn-http-go/internal/request"
+	"github.com/Quak1/learn-http-go/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+func serveOnce(t *testing.T, u *Upgrader, handler func(c *Conn)) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		req, err := request.RequestFromReader(conn)
+		if err != nil {
+			conn.Close()
+			return
+		}
+
+		w := response.NewConnResponseWriter(conn, req.Buffered())
+		c, err := u.Upgrade(w, req)
+		if err != nil {
+			w.Flush()
+			conn.Close()
+			return
+		}
+		handler(c)
+	}()
+
+	return ln.Addr().String()
+}
+
+func echo(c *Conn) {
+	for {
+		messageType, p, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := c.WriteMessage(messageType, p); err != nil {
+			return
+		}
+	}
+}
+
+func dial(t *testing.T, addr, extraHeaders string) (*http.Response, *bufio.Reader, net.Conn) {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	_, err = conn.Write([]byte("GET /ws HTTP/1.1\r\n" +
+		"Host: " + addr + "\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + testKey + "\r\n" +
+		extraHeaders +
+		"\r\n"))
+	require.NoError(t, err)
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	require.NoError(t, err)
+
+	return resp, r, conn
+}
+
+func TestHandshake(t *testing.T) {
+	// Test: Accept key from RFC 6455 example
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", AcceptKey(testKey))
+
+	// Test: Valid upgrade
+	u := &Upgrader{Subprotocols: []string{"chat"}}
+	addr := serveOnce(t, u, echo)
+	resp, _, _ := dial(t, addr, "Sec-WebSocket-Version: 13\r\nSec-WebSocket-Protocol: superchat, chat\r\n")
+	assert.Equal(t, 101, resp.StatusCode)
+	assert.Equal(t, "websocket", resp.Header.Get("Upgrade"))
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+	assert.Equal(t, "chat", resp.Header.Get("Sec-WebSocket-Protocol"))
+
+	// Test: Unsupported version
+	addr = serveOnce(t, &Upgrader{}, echo)
+	resp, _, _ = dial(t, addr, "Sec-WebSocket-Version: 8\r\n")
+	assert.Equal(t, 426, resp.StatusCode)
+	assert.Equal(t, "13", resp.Header.Get("Sec-WebSocket-Version"))
+
+	// Test: Missing Upgrade header
+	addr = serveOnce(t, &Upgrader{}, echo)
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.Write([]byte("GET /ws HTTP/1.1\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: " + testKey + "\r\n\r\n"))
+	resp, err = http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestMessages(t *testing.T) {
+	// Test: Text message echo with fragmented server frames
+	addr := serveOnce(t, &Upgrader{}, func(c *Conn) {
+		c.FragmentSize = 3
+		echo(c)
+	})
+	_, r, conn := dial(t, addr, "Sec-WebSocket-Version: 13\r\n")
+	client := newConn(conn, r, false, false)
+
+	require.NoError(t, client.WriteMessage(TextMessage, []byte("hello world")))
+	f, err := client.readFrame()
+	require.NoError(t, err)
+	assert.False(t, f.fin)
+	assert.Equal(t, byte(TextMessage), f.opcode)
+	assert.Equal(t, "hel", string(f.payload))
+	for range 3 {
+		f, err = client.readFrame()
+		require.NoError(t, err)
+		assert.Equal(t, byte(continuationFrame), f.opcode)
+	}
+	assert.True(t, f.fin)
+
+	// Test: Fragmented client message is reassembled
+	client.FragmentSize = 2
+	require.NoError(t, client.WriteMessage(BinaryMessage, []byte{1, 2, 3, 4, 5}))
+	messageType, p, err := client.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, BinaryMessage, messageType)
+	assert.Equal(t, []byte{1, 2, 3, 4, 5}, p)
+
+	// Test: Ping is answered with pong carrying the same payload
+	require.NoError(t, client.Ping([]byte("are you there")))
+	f, err = client.readFrame()
+	require.NoError(t, err)
+	assert.Equal(t, byte(PongMessage), f.opcode)
+	assert.Equal(t, "are you there", string(f.payload))
+
+	// Test: Close handshake echoes the status code
+	require.NoError(t, client.WriteClose(CloseNormalClosure, "bye"))
+	_, _, err = client.ReadMessage()
+	require.Error(t, err)
+	assert.True(t, IsCloseError(err, CloseNormalClosure))
+}
+
+func TestPingAfterClose(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	server := newConn(a, a, true, false)
+	client := newConn(b, b, false, false)
+
+	go server.WriteClose(CloseGoingAway, "")
+	f, err := client.readFrame()
+	require.NoError(t, err)
+	require.Equal(t, byte(CloseMessage), f.opcode)
+
+	// Test: Ping after our close frame is not answered and reading goes on
+	errs := make(chan error, 1)
+	go func() {
+		_, _, err := server.ReadMessage()
+		errs <- err
+	}()
+	require.NoError(t, client.Ping([]byte("still there?")))
+	require.NoError(t, client.WriteClose(CloseGoingAway, ""))
+	assert.True(t, IsCloseError(<-errs, CloseGoingAway))
+}
+
+func TestCompression(t *testing.T) {
+	// Test: permessage-deflate is negotiated and messages round trip
+	addr := serveOnce(t, &Upgrader{EnableCompression: true}, echo)
+	resp, r, conn := dial(t, addr, "Sec-WebSocket-Version: 13\r\nSec-WebSocket-Extensions: permessage-deflate; client_max_window_bits\r\n")
+	assert.Contains(t, resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+	client := newConn(conn, r, false, true)
+
+	message := []byte("compress me compress me compress me compress me")
+	require.NoError(t, client.WriteMessage(TextMessage, message))
+	messageType, p, err := client.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, TextMessage, messageType)
+	assert.Equal(t, message, p)
+
+	// Test: Compression is not used when the client does not offer it
+	addr = serveOnce(t, &Upgrader{EnableCompression: true}, echo)
+	resp, _, _ = dial(t, addr, "Sec-WebSocket-Version: 13\r\n")
+	assert.Empty(t, resp.Header.Get("Sec-WebSocket-Extensions"))
+}